@@ -0,0 +1,29 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package api contains the OpenAPI specification of the REST API. The
+// specification is embedded in the binary, so it's served regardless of the
+// working directory of the service.
+package api
+
+import (
+	_ "embed" // needed by go:embed
+)
+
+// Spec is the OpenAPI specification in JSON format
+//
+//go:embed openapi.json
+var Spec []byte
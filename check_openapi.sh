@@ -25,7 +25,7 @@ else
 	exit 1
 fi
 
-if "$CONTAINER_RUNTIME" run --rm -v "${PWD}":/local/:Z openapitools/openapi-generator-cli validate -i ./local/openapi.json; then
+if "$CONTAINER_RUNTIME" run --rm -v "${PWD}":/local/:Z openapitools/openapi-generator-cli validate -i ./local/api/openapi.json; then
 	echo "OpenAPI spec file is OK"
 else
 	echo "OpenAPI spec file validation failed"
@@ -29,6 +29,7 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/tracing"
 	"github.com/RedHatInsights/insights-operator-utils/logger"
 	"github.com/spf13/viper"
 )
@@ -49,11 +50,17 @@ const (
 type Configuration struct {
 	ServerConfig        server.Config                     `mapstructure:"server" toml:"server"`
 	AuthConfig          server.AuthConfig                 `mapstructure:"auth" toml:"auth"`
+	AdminServerConfig   server.Config                     `mapstructure:"admin_server" toml:"admin_server"`
+	AdminAuthConfig     server.AuthConfig                 `mapstructure:"admin_auth" toml:"admin_auth"`
+	OperationsConfig    service.OperationsConfig          `mapstructure:"admin_operations" toml:"admin_operations"`
 	StorageConfig       service.StorageConfig             `mapstructure:"storage" toml:"storage"`
 	CanaryConfig        service.CanaryConfig              `mapstructure:"canary" toml:"canary"`
+	GuardrailConfig     service.GuardrailConfig           `mapstructure:"guardrail" toml:"guardrail"`
+	LedgerConfig        service.LedgerConfig              `mapstructure:"ledger" toml:"ledger"`
 	LoggingConfig       logger.LoggingConfiguration       `mapstructure:"logging" toml:"logging"`
 	CloudWatchConfig    logger.CloudWatchConfiguration    `mapstructure:"cloudwatch" toml:"cloudwatch"`
 	SentryLoggingConfig logger.SentryLoggingConfiguration `mapstructure:"sentry" toml:"sentry"`
+	TracingConfig       tracing.Config                    `mapstructure:"tracing" toml:"tracing"`
 }
 
 // Config has exactly the same structure as *.toml file
@@ -126,6 +133,24 @@ func AuthConfig() server.AuthConfig {
 	return Config.AuthConfig
 }
 
+// AdminServerConfig function returns actual configuration of the admin
+// listener serving metrics, health and debug endpoints.
+func AdminServerConfig() server.Config {
+	return Config.AdminServerConfig
+}
+
+// AdminAuthConfig function returns actual auth configuration of the admin
+// listener.
+func AdminAuthConfig() server.AuthConfig {
+	return Config.AdminAuthConfig
+}
+
+// OperationsConfig function returns actual configuration of the admin
+// operations changing the served rules.
+func OperationsConfig() service.OperationsConfig {
+	return Config.OperationsConfig
+}
+
 // StorageConfig function returns actual storage configuration.
 func StorageConfig() service.StorageConfig {
 	return Config.StorageConfig
@@ -136,6 +161,18 @@ func CanaryConfig() service.CanaryConfig {
 	return Config.CanaryConfig
 }
 
+// GuardrailConfig function returns actual configuration of the canary
+// guardrail.
+func GuardrailConfig() service.GuardrailConfig {
+	return Config.GuardrailConfig
+}
+
+// LedgerConfig function returns actual configuration of the ledger of the
+// served remote configurations.
+func LedgerConfig() service.LedgerConfig {
+	return Config.LedgerConfig
+}
+
 // LoggingConfig function returns actual logger configuration.
 func LoggingConfig() logger.LoggingConfiguration {
 	return Config.LoggingConfig
@@ -151,6 +188,11 @@ func SentryLoggingConfig() logger.SentryLoggingConfiguration {
 	return Config.SentryLoggingConfig
 }
 
+// TracingConfig function returns the tracing configuration.
+func TracingConfig() tracing.Config {
+	return Config.TracingConfig
+}
+
 // updateConfigFromClowder updates the current config with the values defined in clowder
 func updateConfigFromClowder(configuration *Configuration) {
 	// check if Clowder is enabled. If not, simply skip the logic.
@@ -161,6 +203,10 @@ func updateConfigFromClowder(configuration *Configuration) {
 
 	fmt.Println("Clowder is enabled")
 
+	if clowder.LoadedConfig.MetricsPort != 0 {
+		configuration.AdminServerConfig.Address = fmt.Sprintf(":%d", clowder.LoadedConfig.MetricsPort)
+	}
+
 	if clowder.LoadedConfig.FeatureFlags != nil {
 		configuration.CanaryConfig.UnleashToken = *clowder.LoadedConfig.FeatureFlags.ClientAccessToken
 	} else {
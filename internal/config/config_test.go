@@ -19,12 +19,14 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	clowder "github.com/redhatinsights/app-common-go/pkg/api/v1"
 
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/config"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/tracing"
 	"github.com/RedHatInsights/insights-operator-utils/logger"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,14 +40,24 @@ const (
 var (
 	validConf = config.Configuration{
 		ServerConfig: server.Config{
-			Address:    "address",
-			UseHTTPS:   true,
-			EnableCORS: true,
+			Address:         "address",
+			UseHTTPS:        true,
+			EnableCORS:      true,
+			ReadTimeout:     10 * time.Second,
+			ShutdownTimeout: time.Minute,
+			CORS: server.CORSConfig{
+				AllowedOrigins: []string{"https://console.redhat.com", "https://*.redhat.com"},
+				MaxAge:         5 * time.Minute,
+			},
 		},
 		AuthConfig: server.AuthConfig{
 			Enabled: false,
 			Type:    "",
 		},
+		OperationsConfig: service.OperationsConfig{
+			Enabled: true,
+			Token:   "0123456789abcdef0123456789abcdef",
+		},
 		StorageConfig: service.StorageConfig{
 			RulesPath:                "rules_path",
 			RemoteConfigurationsPath: "remote_configurations",
@@ -57,9 +69,27 @@ var (
 			UnleashApp:     "default",
 			UnleashToggle:  "insights-operator-gathering-conditions-service",
 		},
+		GuardrailConfig: service.GuardrailConfig{
+			Enabled:           true,
+			MaxErrorRateDelta: 0.1,
+			MinReports:        50,
+			Window:            time.Hour,
+		},
+		LedgerConfig: service.LedgerConfig{
+			Enabled:   true,
+			Driver:    "sqlite",
+			DSN:       "ledger.db",
+			Retention: 720 * time.Hour,
+			QueueSize: 500,
+		},
 		SentryLoggingConfig: logger.SentryLoggingConfiguration{
 			SentryDSN: "dsn",
 		},
+		TracingConfig: tracing.Config{
+			Enabled:     true,
+			Exporter:    tracing.ExporterStdout,
+			SampleRatio: 0.5,
+		},
 	}
 	emptyConfig = config.Configuration{}
 
@@ -185,12 +215,24 @@ func TestGetConfigFunctions(t *testing.T) {
 	t.Run("AuthConfig", func(t *testing.T) {
 		assert.Equal(t, config.Config.AuthConfig, config.AuthConfig())
 	})
+	t.Run("AdminServerConfig", func(t *testing.T) {
+		assert.Equal(t, config.Config.AdminServerConfig, config.AdminServerConfig())
+	})
+	t.Run("AdminAuthConfig", func(t *testing.T) {
+		assert.Equal(t, config.Config.AdminAuthConfig, config.AdminAuthConfig())
+	})
 	t.Run("StorageConfig", func(t *testing.T) {
 		assert.Equal(t, config.Config.StorageConfig, config.StorageConfig())
 	})
 	t.Run("CanaryConfig", func(t *testing.T) {
 		assert.Equal(t, config.Config.CanaryConfig, config.CanaryConfig())
 	})
+	t.Run("GuardrailConfig", func(t *testing.T) {
+		assert.Equal(t, config.Config.GuardrailConfig, config.GuardrailConfig())
+	})
+	t.Run("LedgerConfig", func(t *testing.T) {
+		assert.Equal(t, config.Config.LedgerConfig, config.LedgerConfig())
+	})
 	t.Run("LoggingConfig", func(t *testing.T) {
 		assert.Equal(t, config.Config.LoggingConfig, config.LoggingConfig())
 	})
@@ -200,6 +242,9 @@ func TestGetConfigFunctions(t *testing.T) {
 	t.Run("SentryLoggingConfig", func(t *testing.T) {
 		assert.Equal(t, config.Config.SentryLoggingConfig, config.SentryLoggingConfig())
 	})
+	t.Run("TracingConfig", func(t *testing.T) {
+		assert.Equal(t, config.Config.TracingConfig, config.TracingConfig())
+	})
 }
 
 // TestLoadConfigurationFromClowder tests that when applying the config,
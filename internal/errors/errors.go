@@ -146,6 +146,20 @@ func (*NoBodyError) Error() string {
 	return "client didn't provide request body"
 }
 
+// InvalidBodyError means the request body can't be parsed
+type InvalidBodyError struct {
+	Err error
+}
+
+func (e *InvalidBodyError) Error() string {
+	return fmt.Sprintf("request body can't be parsed: %v", e.Err)
+}
+
+// Unwrap returns the parsing error
+func (e *InvalidBodyError) Unwrap() error {
+	return e.Err
+}
+
 // NotFoundError meaning that the requested resource wasn't found
 type NotFoundError struct {
 	ErrString string
@@ -155,6 +169,83 @@ func (e *NotFoundError) Error() string {
 	return e.ErrString
 }
 
+// StorageNotFoundError means the requested resource doesn't exist in the
+// storage
+type StorageNotFoundError struct {
+	Path string
+}
+
+func (e *StorageNotFoundError) Error() string {
+	return fmt.Sprintf("store data not found for '%s'", e.Path)
+}
+
+// CorruptDataError means the stored resource can't be parsed
+type CorruptDataError struct {
+	Path string
+	Err  error
+}
+
+func (e *CorruptDataError) Error() string {
+	return fmt.Sprintf("store data for '%s' are corrupt: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the parsing error
+func (e *CorruptDataError) Unwrap() error {
+	return e.Err
+}
+
+// StorageIOError means the resource exists, but it can't be read from the
+// storage
+type StorageIOError struct {
+	Path string
+	Err  error
+}
+
+func (e *StorageIOError) Error() string {
+	return fmt.Sprintf("unable to read store data for '%s': %v", e.Path, e.Err)
+}
+
+// Unwrap returns the I/O error
+func (e *StorageIOError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidEntryError means an entry of the stored resource is not valid
+type InvalidEntryError struct {
+	Path string
+	// Kind is the list with the entry, like container_logs
+	Kind  string
+	Index int
+	Err   error
+}
+
+func (e *InvalidEntryError) Error() string {
+	return fmt.Sprintf("%s[%d] of '%s' is invalid: %v", e.Kind, e.Index, e.Path, e.Err)
+}
+
+// Unwrap returns the validation error
+func (e *InvalidEntryError) Unwrap() error {
+	return e.Err
+}
+
+// TooManyRequestsError means the client exceeded the allowed request rate
+type TooManyRequestsError struct {
+	ErrString string
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return e.ErrString
+}
+
+// ConflictError means the request can't be done in the current state
+type ConflictError struct {
+	ErrString string
+}
+
+func (e *ConflictError) Error() string {
+	return e.ErrString
+}
+
 // ValidationError validation error, for example when string is longer then expected
 type ValidationError struct {
 	ParamName  string
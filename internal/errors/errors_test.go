@@ -17,6 +17,7 @@ limitations under the License.
 package errors_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -86,6 +87,57 @@ func TestNoBodyError(t *testing.T) {
 	assert.Equal(t, err.Error(), expected)
 }
 
+// TestInvalidBodyError checks the methods Error() and Unwrap() for data
+// structure InvalidBodyError
+func TestInvalidBodyError(t *testing.T) {
+	cause := fmt.Errorf("unexpected EOF")
+	err := errors.InvalidBodyError{Err: cause}
+
+	assert.Equal(t, "request body can't be parsed: unexpected EOF", err.Error())
+	assert.Equal(t, cause, err.Unwrap())
+}
+
+// TestTooManyRequestsError checks the method Error() for data structure
+// TooManyRequestsError
+func TestTooManyRequestsError(t *testing.T) {
+	err := errors.TooManyRequestsError{
+		ErrString: "errorMessage"}
+
+	const expected = "errorMessage"
+	assert.Equal(t, err.Error(), expected)
+}
+
+// TestConflictError checks the method Error() for data structure
+// ConflictError
+func TestConflictError(t *testing.T) {
+	err := errors.ConflictError{
+		ErrString: "errorMessage"}
+
+	const expected = "errorMessage"
+	assert.Equal(t, err.Error(), expected)
+}
+
+// TestStorageErrors checks the methods Error() and Unwrap() for the storage
+// errors
+func TestStorageErrors(t *testing.T) {
+	cause := fmt.Errorf("cause")
+
+	notFoundErr := errors.StorageNotFoundError{Path: "rules.json"}
+	assert.Equal(t, "store data not found for 'rules.json'", notFoundErr.Error())
+
+	corruptErr := errors.CorruptDataError{Path: "rules.json", Err: cause}
+	assert.Equal(t, "store data for 'rules.json' are corrupt: cause", corruptErr.Error())
+	assert.Equal(t, cause, corruptErr.Unwrap())
+
+	ioErr := errors.StorageIOError{Path: "rules.json", Err: cause}
+	assert.Equal(t, "unable to read store data for 'rules.json': cause", ioErr.Error())
+	assert.Equal(t, cause, ioErr.Unwrap())
+
+	invalidErr := errors.InvalidEntryError{Path: "config.json", Kind: "container_logs", Index: 2, Err: cause}
+	assert.Equal(t, "container_logs[2] of 'config.json' is invalid: cause", invalidErr.Error())
+	assert.Equal(t, cause, invalidErr.Unwrap())
+}
+
 // TestRouterParsingError checks the method Error() for data structure
 // ValidationError
 func TestValidationError(t *testing.T) {
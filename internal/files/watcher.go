@@ -0,0 +1,82 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package files contains helper functions to write and watch the files
+// read by the service.
+package files
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// Watcher calls the reload function when the files in the watched
+// directories change
+type Watcher struct {
+	watcher *fsnotify.Watcher
+}
+
+// WatchDirs starts watching the directories, the name describes the watched
+// files in the logs. The directories are watched instead of the files because config
+// maps and secrets mounted in a pod are replaced by swapping a symlink.
+func WatchDirs(name string, dirs []string, reload func() error) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+					continue
+				}
+				if err := reload(); err != nil {
+					// the files may be only partially written, keep the old
+					// content and wait for the next event
+					log.Warn().Err(err).Str("watched", name).Str("event", event.String()).Msg("Unable to reload the watched files")
+					continue
+				}
+				log.Info().Str("watched", name).Str("path", event.Name).Msg("Watched files reloaded")
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Str("watched", name).Msg("File watcher failure")
+			}
+		}
+	}()
+
+	return &Watcher{watcher: watcher}, nil
+}
+
+// Close stops watching the directories, it does nothing for nil watcher
+func (w *Watcher) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.watcher.Close()
+}
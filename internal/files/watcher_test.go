@@ -0,0 +1,79 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package files_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/files"
+)
+
+func TestWatchDirs(t *testing.T) {
+	dir := t.TempDir()
+	var reloads atomic.Int32
+	watcher, err := files.WatchDirs("test", []string{dir}, func() error {
+		reloads.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, watcher.Close())
+	}()
+
+	// a replaced file is reloaded like a config map with swapped symlink
+	require.NoError(t, files.WriteAtomic(filepath.Join(dir, "file.json"), []byte("{}"), 0o600))
+	assert.Eventually(t, func() bool { return reloads.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatchDirsReloadFailure(t *testing.T) {
+	dir := t.TempDir()
+	var reloads atomic.Int32
+	watcher, err := files.WatchDirs("test", []string{dir}, func() error {
+		reloads.Add(1)
+		return errors.New("partially written")
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, watcher.Close())
+	}()
+
+	// the watcher keeps running after a failed reload
+	path := filepath.Join(dir, "file.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	assert.Eventually(t, func() bool { return reloads.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+	seen := reloads.Load()
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+	assert.Eventually(t, func() bool { return reloads.Load() > seen }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatchDirsMissingDirectory(t *testing.T) {
+	_, err := files.WatchDirs("test", []string{filepath.Join(t.TempDir(), "missing")}, func() error { return nil })
+	assert.Error(t, err)
+}
+
+func TestWatcherCloseNil(t *testing.T) {
+	var watcher *files.Watcher
+	assert.NoError(t, watcher.Close())
+}
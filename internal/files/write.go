@@ -0,0 +1,38 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package files
+
+import (
+	"os"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// WriteAtomic replaces the file at once by writing a temporary file next to
+// it and renaming it, so the readers never see a partially written file.
+// The callers writing the same file concurrently must serialize the writes,
+// because they share the temporary file.
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, perm); err != nil {
+		return &merrors.StorageIOError{Path: tmpPath, Err: err}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return &merrors.StorageIOError{Path: path, Err: err}
+	}
+	return nil
+}
@@ -34,6 +34,7 @@ const (
 	malformedTokenMessage = "Malformed authentication token"
 	invalidTokenMessage   = "Invalid/Malformed auth token"
 	jwtAuthType           = "jwt"
+	mtlsAuthType          = "mtls"
 )
 
 // ContextKey is a type for user authentication token in request
@@ -80,6 +81,20 @@ func (server *Server) Authentication(next http.Handler, noAuthURLs []string) htt
 			return
 		}
 
+		// client certificate was already verified during TLS handshake,
+		// its subject is used as the caller identity
+		if server.AuthConfig.Type == mtlsAuthType {
+			identity, err := ClientCertificateIdentity(r)
+			if err != nil {
+				log.Error().Err(err).Msg(err.Error())
+				HandleServerError(w, err)
+				return
+			}
+			ctx := context.WithValue(r.Context(), ContextKeyUser, *identity)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// try to read auth. header from HTTP request (if provided by client)
 		token, err := server.getAuthTokenHeader(w, r)
 		if err != nil {
@@ -179,6 +194,28 @@ func (server *Server) GetAuthToken(request *http.Request) (*Identity, error) {
 	return &identity, nil
 }
 
+// ClientCertificateSubject returns the subject of the verified client
+// certificate used for the TLS connection
+func ClientCertificateSubject(request *http.Request) (string, error) {
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return "", &errors.UnauthorizedError{ErrString: "Missing client certificate"}
+	}
+	return request.TLS.VerifiedChains[0][0].Subject.String(), nil
+}
+
+// ClientCertificateIdentity maps the verified client certificate to the
+// identity used by the rest of the service. The common name is used as
+// the account number.
+func ClientCertificateIdentity(request *http.Request) (*Identity, error) {
+	if _, err := ClientCertificateSubject(request); err != nil {
+		return nil, err
+	}
+	cert := request.TLS.VerifiedChains[0][0]
+	return &Identity{
+		AccountNumber: UserID(cert.Subject.CommonName),
+	}, nil
+}
+
 func (server *Server) getAuthTokenHeader(_ http.ResponseWriter, r *http.Request) (string, error) {
 	var tokenHeader string
 	// In case of testing on local machine we don't take x-rh-identity
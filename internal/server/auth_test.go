@@ -296,3 +296,27 @@ func TestAuth(t *testing.T) {
 		})
 	}
 }
+
+// TestNoAuthURLs checks that the configured URLs are served without
+// authentication
+func TestNoAuthURLs(t *testing.T) {
+	router := mux.NewRouter().StrictSlash(true)
+	router.HandleFunc(testedEndpoint, dummyHandler)
+	testServer := server.New(serverConfig, configAuth2, router)
+	testServer.NoAuthURLs = []string{testedEndpoint}
+
+	go func() {
+		err := testServer.Start()
+		assert.NoError(t, err)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	res, err := http.Get(testedURL) // #nosec G107 -- test server URL
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, res.Body.Close())
+	}
+
+	err = testServer.Stop(context.TODO())
+	assert.NoError(t, err)
+}
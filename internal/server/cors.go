@@ -0,0 +1,226 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+)
+
+const (
+	anyOrigin         = "*"
+	wildcardSubdomain = "*."
+
+	// browsers don't cache preflight responses for longer than this
+	maxCORSMaxAge = 10 * time.Minute
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet}
+	defaultCORSHeaders = []string{
+		"Content-Type",
+		"Content-Length",
+		"Accept-Encoding",
+		"X-CSRF-Token",
+		"Authorization",
+	}
+
+	corsMethods = map[string]bool{
+		http.MethodGet:     true,
+		http.MethodHead:    true,
+		http.MethodPost:    true,
+		http.MethodPut:     true,
+		http.MethodPatch:   true,
+		http.MethodDelete:  true,
+		http.MethodOptions: true,
+	}
+
+	// header names are tokens as defined by RFC 9110
+	headerNameRegexp = regexp.MustCompile("^[A-Za-z0-9!#$%&'+.^_`|~-]+$")
+)
+
+// CORSConfig structure represents the CORS policy used when enable_cors is
+// set. An allowed origin is either exact (https://console.redhat.com), a
+// pattern matching any subdomain (https://*.redhat.com) or "*" matching all
+// origins. Methods and headers fall back to the defaults when not set.
+type CORSConfig struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods   []string      `mapstructure:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers" toml:"allowed_headers"`
+	ExposedHeaders   []string      `mapstructure:"exposed_headers" toml:"exposed_headers"`
+	MaxAge           time.Duration `mapstructure:"max_age" toml:"max_age"`
+	AllowCredentials bool          `mapstructure:"allow_credentials" toml:"allow_credentials"`
+}
+
+// originPattern matches the Origin header against single allowed origin
+type originPattern struct {
+	scheme string
+	// host including the port, without the wildcard label
+	host     string
+	wildcard bool
+}
+
+// parseOriginPattern parses allowed origin like https://*.example.com:8443
+func parseOriginPattern(origin string) (originPattern, error) {
+	scheme, host, found := strings.Cut(strings.ToLower(origin), "://")
+	if !found || (scheme != "http" && scheme != "https") {
+		return originPattern{}, fmt.Errorf("origin '%s' must start with http:// or https://", origin)
+	}
+
+	pattern := originPattern{scheme: scheme, host: host}
+	if rest, isWildcard := strings.CutPrefix(host, wildcardSubdomain); isWildcard {
+		pattern.host = rest
+		pattern.wildcard = true
+	}
+	if pattern.host == "" || strings.ContainsAny(pattern.host, "*/?#@ ") {
+		return originPattern{}, fmt.Errorf("invalid origin '%s'", origin)
+	}
+	return pattern, nil
+}
+
+func (p originPattern) matches(origin string) bool {
+	scheme, host, found := strings.Cut(strings.ToLower(origin), "://")
+	if !found || scheme != p.scheme {
+		return false
+	}
+	if !p.wildcard {
+		return host == p.host
+	}
+	subdomain, found := strings.CutSuffix(host, "."+p.host)
+	return found && subdomain != "" && !strings.ContainsAny(subdomain, ":/@")
+}
+
+// parseOrigins parses all the allowed origins. It reports whether any origin
+// is allowed instead of returning the patterns in that case.
+func parseOrigins(origins []string) (patterns []originPattern, allowAll bool, err error) {
+	for _, origin := range origins {
+		if origin == anyOrigin {
+			allowAll = true
+			continue
+		}
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, false, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, allowAll, nil
+}
+
+func validateHeaderNames(option string, headers []string) error {
+	for _, header := range headers {
+		if !headerNameRegexp.MatchString(header) {
+			return fmt.Errorf("invalid header name '%s' in %s", header, option)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the CORS policy is well formed and safe. Namely the
+// credentials can't be allowed for all origins.
+func (cfg CORSConfig) Validate() error {
+	if len(cfg.AllowedOrigins) == 0 {
+		return fmt.Errorf("CORS is enabled, but allowed_origins is empty")
+	}
+	_, allowAll, err := parseOrigins(cfg.AllowedOrigins)
+	if err != nil {
+		return err
+	}
+	if allowAll && cfg.AllowCredentials {
+		return fmt.Errorf("allow_credentials can't be used together with '*' origin")
+	}
+
+	for _, method := range cfg.AllowedMethods {
+		if !corsMethods[strings.ToUpper(method)] {
+			return fmt.Errorf("unsupported method '%s' in allowed_methods", method)
+		}
+	}
+	if err := validateHeaderNames("allowed_headers", cfg.AllowedHeaders); err != nil {
+		return err
+	}
+	if err := validateHeaderNames("exposed_headers", cfg.ExposedHeaders); err != nil {
+		return err
+	}
+
+	if cfg.MaxAge < 0 || cfg.MaxAge > maxCORSMaxAge {
+		return fmt.Errorf("max_age must be between 0 and %s", maxCORSMaxAge)
+	}
+	return nil
+}
+
+// CORSMiddleware handles CORS HTTP headers according to given policy
+// For more info see https://en.wikipedia.org/wiki/Cross-origin_resource_sharing
+func CORSMiddleware(cfg CORSConfig) (mux.MiddlewareFunc, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	patterns, allowAll, err := parseOrigins(cfg.AllowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+
+	options := []handlers.CORSOption{
+		handlers.AllowedMethods(methods),
+		handlers.AllowedHeaders(headers),
+		handlers.ExposedHeaders(cfg.ExposedHeaders),
+		handlers.MaxAge(int(cfg.MaxAge.Seconds())),
+	}
+	if allowAll {
+		options = append(options, handlers.AllowedOrigins([]string{anyOrigin}))
+	} else {
+		options = append(options, handlers.AllowedOriginValidator(func(origin string) bool {
+			for _, pattern := range patterns {
+				if pattern.matches(origin) {
+					return true
+				}
+			}
+			return false
+		}))
+	}
+	if cfg.AllowCredentials {
+		options = append(options, handlers.AllowCredentials())
+	}
+
+	corsHandler := handlers.CORS(options...)
+	return func(next http.Handler) http.Handler {
+		handler := corsHandler(next)
+		if allowAll {
+			return handler
+		}
+		// the allowed origin is echoed back, so the responses differ by
+		// the Origin header
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+			handler.ServeHTTP(w, r)
+		})
+	}, nil
+}
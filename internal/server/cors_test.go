@@ -0,0 +1,255 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+)
+
+// TestCORSConfigValidate checks that invalid or unsafe CORS policies are
+// rejected
+func TestCORSConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        server.CORSConfig
+		expectedError string
+	}{
+		{
+			name: "exact and wildcard origins",
+			config: server.CORSConfig{
+				AllowedOrigins:   []string{"https://console.redhat.com", "https://*.redhat.com:8443"},
+				AllowedMethods:   []string{"get", http.MethodOptions},
+				AllowedHeaders:   []string{"Authorization"},
+				ExposedHeaders:   []string{"Retry-After"},
+				MaxAge:           10 * time.Minute,
+				AllowCredentials: true,
+			},
+		},
+		{
+			name:   "any origin without credentials",
+			config: server.CORSConfig{AllowedOrigins: []string{"*"}},
+		},
+		{
+			name:          "no origins",
+			config:        server.CORSConfig{},
+			expectedError: "allowed_origins is empty",
+		},
+		{
+			name: "any origin with credentials",
+			config: server.CORSConfig{
+				AllowedOrigins:   []string{"https://console.redhat.com", "*"},
+				AllowCredentials: true,
+			},
+			expectedError: "allow_credentials can't be used together with '*' origin",
+		},
+		{
+			name:          "origin without scheme",
+			config:        server.CORSConfig{AllowedOrigins: []string{"console.redhat.com"}},
+			expectedError: "must start with http:// or https://",
+		},
+		{
+			name:          "wildcard in the middle of origin",
+			config:        server.CORSConfig{AllowedOrigins: []string{"https://console.*.com"}},
+			expectedError: "invalid origin",
+		},
+		{
+			name:          "origin with path",
+			config:        server.CORSConfig{AllowedOrigins: []string{"https://console.redhat.com/"}},
+			expectedError: "invalid origin",
+		},
+		{
+			name: "unknown method",
+			config: server.CORSConfig{
+				AllowedOrigins: []string{"*"},
+				AllowedMethods: []string{"FETCH"},
+			},
+			expectedError: "unsupported method 'FETCH'",
+		},
+		{
+			name: "invalid header",
+			config: server.CORSConfig{
+				AllowedOrigins: []string{"*"},
+				ExposedHeaders: []string{"Retry After"},
+			},
+			expectedError: "invalid header name 'Retry After' in exposed_headers",
+		},
+		{
+			name: "max age too long",
+			config: server.CORSConfig{
+				AllowedOrigins: []string{"*"},
+				MaxAge:         time.Hour,
+			},
+			expectedError: "max_age must be between",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func corsRequest(t *testing.T, cfg server.CORSConfig, method, origin string) *httptest.ResponseRecorder {
+	middleware, err := server.CORSMiddleware(cfg)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(method, testedEndpoint, http.NoBody)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	rr := httptest.NewRecorder()
+	middleware(http.HandlerFunc(dummyHandler)).ServeHTTP(rr, req)
+	return rr
+}
+
+// TestCORSMiddlewareOrigins checks which origins are allowed by the policy
+func TestCORSMiddlewareOrigins(t *testing.T) {
+	cfg := server.CORSConfig{
+		AllowedOrigins:   []string{"https://console.redhat.com", "https://*.openshift.com"},
+		ExposedHeaders:   []string{"Retry-After"},
+		AllowCredentials: true,
+	}
+
+	testCases := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://console.redhat.com", true},
+		{"https://CONSOLE.redhat.com", true},
+		{"https://cloud.openshift.com", true},
+		{"https://a.b.openshift.com", true},
+		{"http://console.redhat.com", false},
+		{"https://openshift.com", false},
+		{"https://evilopenshift.com", false},
+		{"https://console.redhat.com.evil.com", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.origin, func(t *testing.T) {
+			rr := corsRequest(t, cfg, http.MethodGet, tc.origin)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Header().Values("Vary"), "Origin")
+			if tc.allowed {
+				assert.Equal(t, tc.origin, rr.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+				assert.Equal(t, "Retry-After", rr.Header().Get("Access-Control-Expose-Headers"))
+			} else {
+				assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+				assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+			}
+		})
+	}
+}
+
+// TestCORSMiddlewarePreflight checks the preflight responses
+func TestCORSMiddlewarePreflight(t *testing.T) {
+	cfg := server.CORSConfig{
+		AllowedOrigins: []string{"*"},
+		MaxAge:         5 * time.Minute,
+	}
+
+	rr := corsRequest(t, cfg, http.MethodOptions, "https://example.com")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "300", rr.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+
+	// only GET is allowed by default
+	middleware, err := server.CORSMiddleware(cfg)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodOptions, testedEndpoint, http.NoBody)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	rr = httptest.NewRecorder()
+	middleware(http.HandlerFunc(dummyHandler)).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+// TestCORSPreflightThroughRouter checks the preflight requests of the routes
+// without OPTIONS method are answered by the server
+func TestCORSPreflightThroughRouter(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc(testedEndpoint, dummyHandler).Methods(http.MethodGet, http.MethodPost)
+	httpServer, err := server.NewHTTPServer(server.Config{
+		EnableCORS: true,
+		CORS: server.CORSConfig{
+			AllowedOrigins: []string{"https://console.redhat.com"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPost},
+			AllowedHeaders: []string{"Content-Type", "X-Request-ID"},
+			MaxAge:         5 * time.Minute,
+		},
+	}, router)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodOptions, testedEndpoint, http.NoBody)
+	req.Header.Set("Origin", "https://console.redhat.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "X-Request-ID")
+	rr := httptest.NewRecorder()
+	httpServer.Handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://console.redhat.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-Id", rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "300", rr.Header().Get("Access-Control-Max-Age"))
+
+	// the methods out of the policy are not allowed
+	req = httptest.NewRequest(http.MethodOptions, testedEndpoint, http.NoBody)
+	req.Header.Set("Origin", "https://console.redhat.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	rr = httptest.NewRecorder()
+	httpServer.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	// the other requests are still routed
+	req = httptest.NewRequest(http.MethodGet, testedEndpoint, http.NoBody)
+	req.Header.Set("Origin", "https://console.redhat.com")
+	rr = httptest.NewRecorder()
+	httpServer.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://console.redhat.com", rr.Header().Get("Access-Control-Allow-Origin"))
+
+	_, err = server.NewHTTPServer(server.Config{EnableCORS: true}, router)
+	assert.Error(t, err)
+}
+
+// TestCORSMiddlewareInvalidConfig checks that the middleware can't be
+// constructed with invalid policy
+func TestCORSMiddlewareInvalidConfig(t *testing.T) {
+	_, err := server.CORSMiddleware(server.CORSConfig{
+		AllowedOrigins:   []string{"*"},
+		AllowCredentials: true,
+	})
+	assert.Error(t, err)
+}
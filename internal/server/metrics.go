@@ -0,0 +1,15 @@
+package server
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// RateLimitedRequestsMetric counts requests rejected by the rate limiter
+	RateLimitedRequestsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_rate_limited_requests",
+			Help: "The number of requests rejected by the rate limiter",
+		},
+		[]string{"route", "key_type"})
+)
@@ -17,31 +17,50 @@ limitations under the License.
 package server
 
 import (
+	"fmt"
 	"net/http"
+	"runtime/debug"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
 )
 
-// CORSMiddleware handles CORS HTTP headers
-// For more info see https://en.wikipedia.org/wiki/Cross-origin_resource_sharing
-func CORSMiddleware() mux.MiddlewareFunc {
-	headersOK := handlers.AllowedHeaders([]string{
-		"Content-Type",
-		"Content-Length",
-		"Accept-Encoding",
-		"X-CSRF-Token",
-		"Authorization",
+// RecoveryMiddleware turns panics in the handlers into Internal Server Error
+// responses instead of dropping the connection
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				// used by the handlers to abort the response on purpose
+				panic(recovered)
+			}
+			log.Error().
+				Str("method", r.Method).
+				Str("url", r.URL.Path).
+				Bytes("stack", debug.Stack()).
+				Msg("Recovered from panic in HTTP handler")
+			HandleServerError(w, fmt.Errorf("panic in HTTP handler: %v", recovered))
+		}()
+		next.ServeHTTP(w, r)
 	})
-	originsOK := handlers.AllowedOrigins([]string{"*"})
-	methodsOK := handlers.AllowedMethods([]string{
-		http.MethodPost,
-		http.MethodGet,
-		http.MethodOptions,
-		http.MethodPut,
-		http.MethodDelete,
-	})
-	credsOK := handlers.AllowCredentials()
+}
 
-	return handlers.CORS(originsOK, headersOK, methodsOK, credsOK)
+// BodyLimitMiddleware limits the size of request body. Reading more than
+// limit bytes from the body returns *http.MaxBytesError that is handled by
+// HandleServerError.
+func BodyLimitMiddleware(limit int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				HandleServerError(w, &http.MaxBytesError{Limit: limit})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,106 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+)
+
+// TestRecoveryMiddleware checks that a panic in handler is turned into
+// Internal Server Error response
+func TestRecoveryMiddleware(t *testing.T) {
+	handler := server.RecoveryMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		panic("something went wrong")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, testedEndpoint, http.NoBody))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Internal Server Error")
+	assert.NotContains(t, rr.Body.String(), "something went wrong")
+}
+
+// TestRecoveryMiddlewareAbortHandler checks that http.ErrAbortHandler is
+// propagated to the HTTP server
+func TestRecoveryMiddlewareAbortHandler(t *testing.T) {
+	handler := server.RecoveryMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testedEndpoint, http.NoBody))
+	})
+}
+
+// TestBodyLimitMiddleware checks that too large request bodies are rejected
+func TestBodyLimitMiddleware(t *testing.T) {
+	const limit = 10
+
+	handler := server.BodyLimitMiddleware(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		name           string
+		body           string
+		chunked        bool
+		expectedStatus int
+	}{
+		{
+			name:           "body within limit",
+			body:           "0123456789",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "content length over limit",
+			body:           "0123456789A",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "body without content length over limit",
+			body:           "0123456789A",
+			chunked:        true,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, testedEndpoint, strings.NewReader(tc.body))
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
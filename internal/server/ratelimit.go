@@ -0,0 +1,231 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+const (
+	clusterKeyType = "cluster"
+	addressKeyType = "address"
+
+	// limiters not used for this long are forgotten
+	limiterIdleTimeout = 10 * time.Minute
+
+	// defaultMaxClusters is the number of the cluster buckets kept when
+	// max_clusters is not configured
+	defaultMaxClusters = 10000
+)
+
+// RateLimitConfig structure represents the rate limiter settings. The
+// default limit applies to every route without its own entry in Routes.
+// MaxClusters caps the number of the cluster buckets, the clusters over the
+// cap are limited by their remote address.
+type RateLimitConfig struct {
+	Enabled           bool             `mapstructure:"enabled" toml:"enabled"`
+	RequestsPerSecond float64          `mapstructure:"requests_per_second" toml:"requests_per_second"`
+	Burst             int              `mapstructure:"burst" toml:"burst"`
+	MaxClusters       int              `mapstructure:"max_clusters" toml:"max_clusters"`
+	Routes            []RouteRateLimit `mapstructure:"routes" toml:"routes"`
+}
+
+// RouteRateLimit overrides the default limit for a single route. Path is the
+// route template as registered in the router, for example
+// /api/gathering/v2/{ocpVersion}/gathering_rules
+type RouteRateLimit struct {
+	Path              string  `mapstructure:"path" toml:"path"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" toml:"requests_per_second"`
+	Burst             int     `mapstructure:"burst" toml:"burst"`
+}
+
+// KeyFunc returns the key used to identify the client, for example the
+// cluster ID. Empty key means the client could not be identified.
+type KeyFunc func(r *http.Request) string
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+	cluster  bool
+}
+
+// RateLimiter keeps one token bucket per route and client
+type RateLimiter struct {
+	config      RateLimitConfig
+	keyFunc     KeyFunc
+	mutex       sync.Mutex
+	limiters    map[string]*limiterEntry
+	clusters    int
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+// NewRateLimiter constructs new rate limiter. When keyFunc is nil or returns
+// an empty key, clients are identified by their remote address.
+func NewRateLimiter(cfg RateLimitConfig, keyFunc KeyFunc) *RateLimiter {
+	return &RateLimiter{
+		config:   cfg,
+		keyFunc:  keyFunc,
+		limiters: map[string]*limiterEntry{},
+		now:      time.Now,
+	}
+}
+
+// limitForRoute returns the limit and burst configured for given route
+func (rl *RateLimiter) limitForRoute(route string) (rate.Limit, int) {
+	for _, routeLimit := range rl.config.Routes {
+		if routeLimit.Path == route {
+			return rate.Limit(routeLimit.RequestsPerSecond), routeLimit.Burst
+		}
+	}
+	return rate.Limit(rl.config.RequestsPerSecond), rl.config.Burst
+}
+
+// maxClusters returns the configured cap of the cluster buckets
+func (rl *RateLimiter) maxClusters() int {
+	if rl.config.MaxClusters > 0 {
+		return rl.config.MaxClusters
+	}
+	return defaultMaxClusters
+}
+
+// clusterKey identifies the client by the key function. Empty key means the
+// client has to be identified by its remote address.
+func (rl *RateLimiter) clusterKey(r *http.Request) string {
+	if rl.keyFunc == nil {
+		return ""
+	}
+	return rl.keyFunc(r)
+}
+
+// remoteAddress returns the remote address of the request without the port
+func remoteAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// reserve takes a token from the bucket of given route and client. The
+// cluster ID is sent by the client, so when all the cluster buckets are
+// taken the new clusters fall back to the bucket of their address. It
+// returns zero when the request is allowed or the time to wait otherwise,
+// together with the key and the type of the used bucket.
+func (rl *RateLimiter) reserve(route, clusterKey, address string) (delay time.Duration, key, keyType string) {
+	key, keyType = address, addressKeyType
+	if clusterKey != "" {
+		key, keyType = clusterKey, clusterKeyType
+	}
+
+	limit, burst := rl.limitForRoute(route)
+	if limit <= 0 {
+		// no limit configured for this route
+		return 0, key, keyType
+	}
+
+	now := rl.now()
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	rl.cleanup(now)
+
+	entry, found := rl.limiters[bucketKey(route, key, keyType)]
+	if !found && keyType == clusterKeyType && rl.clusters >= rl.maxClusters() {
+		key, keyType = address, addressKeyType
+		entry, found = rl.limiters[bucketKey(route, key, keyType)]
+	}
+	if !found {
+		entry = &limiterEntry{limiter: rate.NewLimiter(limit, burst), cluster: keyType == clusterKeyType}
+		rl.limiters[bucketKey(route, key, keyType)] = entry
+		if entry.cluster {
+			rl.clusters++
+		}
+	}
+	entry.lastSeen = now
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		// burst is lower than one, the request can never be served
+		return time.Second, key, keyType
+	}
+	delay = reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+	return delay, key, keyType
+}
+
+// bucketKey returns the key of the bucket of given route and client
+func bucketKey(route, key, keyType string) string {
+	return route + "|" + keyType + "|" + key
+}
+
+// cleanup forgets the limiters that were not used recently. It has to be
+// called with the mutex locked.
+func (rl *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(rl.lastCleanup) < limiterIdleTimeout {
+		return
+	}
+	for key, entry := range rl.limiters {
+		if now.Sub(entry.lastSeen) > limiterIdleTimeout {
+			delete(rl.limiters, key)
+			if entry.cluster {
+				rl.clusters--
+			}
+		}
+	}
+	rl.lastCleanup = now
+}
+
+// Middleware returns the rate limiting middleware. It has to be used with a
+// mux.Router so the matched route template is known.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		delay, key, keyType := rl.reserve(route, rl.clusterKey(r), remoteAddress(r))
+		if delay == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		RateLimitedRequestsMetric.WithLabelValues(route, keyType).Inc()
+		log.Debug().Str("route", route).Str(keyType, key).Dur("retryAfter", delay).Msg("Request rate limited")
+
+		retryAfter := int(math.Ceil(delay.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		HandleServerError(w, &errors.TooManyRequestsError{ErrString: "Too many requests"})
+	})
+}
@@ -0,0 +1,149 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+)
+
+const (
+	limitedRoute   = "/v2/{ocpVersion}/gathering_rules"
+	unlimitedRoute = "/openapi.json"
+)
+
+// the refill rate is so low that no token is added during the test
+var rateLimitConfig = server.RateLimitConfig{
+	Enabled:           true,
+	RequestsPerSecond: 0.001,
+	Burst:             2,
+	Routes: []server.RouteRateLimit{
+		{Path: unlimitedRoute, RequestsPerSecond: 0},
+	},
+}
+
+func rateLimitedRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc(limitedRoute, dummyHandler)
+	router.HandleFunc(unlimitedRoute, dummyHandler)
+	router.Use(server.NewRateLimiter(rateLimitConfig, func(r *http.Request) string {
+		return r.Header.Get("X-Cluster")
+	}).Middleware)
+	return router
+}
+
+func doRequest(router http.Handler, url, cluster, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, http.NoBody)
+	req.RemoteAddr = remoteAddr
+	if cluster != "" {
+		req.Header.Set("X-Cluster", cluster)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestRateLimiterPerCluster checks that the bucket is shared by all the
+// requests of the same cluster on the same route
+func TestRateLimiterPerCluster(t *testing.T) {
+	router := rateLimitedRouter()
+	rejected := testutil.ToFloat64(server.RateLimitedRequestsMetric.WithLabelValues(limitedRoute, "cluster"))
+
+	for i := 0; i < 2; i++ {
+		rr := doRequest(router, "/v2/4.17.0/gathering_rules", "cluster-1", "10.0.0.1:1234")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// different version of the same route uses the same bucket
+	rr := doRequest(router, "/v2/4.18.0/gathering_rules", "cluster-1", "10.0.0.2:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), "Too many requests")
+	assert.Equal(t, rejected+1, testutil.ToFloat64(server.RateLimitedRequestsMetric.WithLabelValues(limitedRoute, "cluster")))
+
+	// other clusters are not affected
+	rr = doRequest(router, "/v2/4.17.0/gathering_rules", "cluster-2", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// TestRateLimiterRemoteAddressFallback checks that clients without cluster ID
+// are limited by their remote address
+func TestRateLimiterRemoteAddressFallback(t *testing.T) {
+	router := rateLimitedRouter()
+
+	for i := 0; i < 2; i++ {
+		rr := doRequest(router, "/v2/4.17.0/gathering_rules", "", "10.0.0.3:1234")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// source port is ignored
+	rr := doRequest(router, "/v2/4.17.0/gathering_rules", "", "10.0.0.3:4321")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	rr = doRequest(router, "/v2/4.17.0/gathering_rules", "", "10.0.0.4:1234")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// TestRateLimiterMaxClusters checks that the clusters over the cap of the
+// cluster buckets are limited by their remote address
+func TestRateLimiterMaxClusters(t *testing.T) {
+	config := rateLimitConfig
+	config.MaxClusters = 1
+	router := mux.NewRouter()
+	router.HandleFunc(limitedRoute, dummyHandler)
+	router.Use(server.NewRateLimiter(config, func(r *http.Request) string {
+		return r.Header.Get("X-Cluster")
+	}).Middleware)
+	rejected := testutil.ToFloat64(server.RateLimitedRequestsMetric.WithLabelValues(limitedRoute, "address"))
+
+	for i := 0; i < 2; i++ {
+		rr := doRequest(router, "/v2/4.17.0/gathering_rules", "cluster-1", "10.0.0.5:1234")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// the new clusters share the bucket of their address
+	for _, cluster := range []string{"cluster-2", "cluster-3"} {
+		rr := doRequest(router, "/v2/4.17.0/gathering_rules", cluster, "10.0.0.6:1234")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	rr := doRequest(router, "/v2/4.17.0/gathering_rules", "cluster-4", "10.0.0.6:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, rejected+1, testutil.ToFloat64(server.RateLimitedRequestsMetric.WithLabelValues(limitedRoute, "address")))
+
+	// the cluster with its own bucket is still limited by it
+	rr = doRequest(router, "/v2/4.17.0/gathering_rules", "cluster-1", "10.0.0.6:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	rr = doRequest(router, "/v2/4.17.0/gathering_rules", "cluster-5", "10.0.0.7:1234")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// TestRateLimiterRouteOverride checks that the route specific limit is used
+func TestRateLimiterRouteOverride(t *testing.T) {
+	router := rateLimitedRouter()
+
+	for i := 0; i < 10; i++ {
+		rr := doRequest(router, unlimitedRoute, "cluster-1", "10.0.0.1:1234")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+)
+
+const loggedRoute = "/v2/{ocpVersion}/gathering_rules"
+
+// captureLogs redirects the global logger to a buffer for the test duration
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	original := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() {
+		log.Logger = original
+	})
+	return &buf
+}
+
+// accessLogLines returns the access log lines written to the buffer
+func accessLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &entry))
+		if entry["message"] == "Request served" {
+			lines = append(lines, entry)
+		}
+	}
+	return lines
+}
+
+func loggedRouter(handler http.HandlerFunc) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc(loggedRoute, handler)
+	router.Use(server.RequestIDMiddleware)
+	router.Use(server.AccessLogMiddleware)
+	return router
+}
+
+// TestRequestIDMiddleware checks that the request ID is accepted from the
+// client or generated
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name       string
+		requestID  string
+		expectSame bool
+	}{
+		{"ID provided by client", "0d8d3c4e-6f0c-4a7e-9a55-3b1f0e9c6f21", true},
+		{"missing ID", "", false},
+		{"ID with invalid characters", "id\"with quotes", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var loggerID interface{}
+			router := loggedRouter(func(w http.ResponseWriter, r *http.Request) {
+				var buf bytes.Buffer
+				logger := zerolog.Ctx(r.Context()).Output(&buf)
+				logger.Info().Msg("handler")
+				var entry map[string]interface{}
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+				loggerID = entry["requestID"]
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v2/4.17.0/gathering_rules", http.NoBody)
+			if tc.requestID != "" {
+				req.Header.Set(server.RequestIDHeader, tc.requestID)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			requestID := rr.Header().Get(server.RequestIDHeader)
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, requestID, loggerID)
+			if tc.expectSame {
+				assert.Equal(t, tc.requestID, requestID)
+			} else {
+				assert.NotEqual(t, tc.requestID, requestID)
+			}
+		})
+	}
+}
+
+// TestAccessLogMiddleware checks that one access log line is written with
+// the fields added by the handler
+func TestAccessLogMiddleware(t *testing.T) {
+	buf := captureLogs(t)
+
+	router := loggedRouter(func(w http.ResponseWriter, r *http.Request) {
+		zerolog.Ctx(r.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("channel", "canary")
+		})
+		server.HandleServerError(w, &errors.NotFoundError{ErrString: "not found"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/1.0.0/gathering_rules", http.NoBody)
+	req.Header.Set(server.RequestIDHeader, "request-1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"request_id":"request-1"`)
+
+	lines := accessLogLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "request-1", lines[0]["requestID"])
+	assert.Equal(t, "canary", lines[0]["channel"])
+	assert.Equal(t, loggedRoute, lines[0]["route"])
+	assert.Equal(t, "/v2/1.0.0/gathering_rules", lines[0]["path"])
+	assert.Equal(t, float64(http.StatusNotFound), lines[0]["status"])
+	assert.Contains(t, lines[0], "latency")
+	assert.Equal(t, float64(rr.Body.Len()), lines[0]["bytes"])
+}
@@ -24,10 +24,57 @@ import (
 )
 
 const (
-	contentType = "Content-Type"
-	appJSON     = "application/json; charset=utf-8"
+	contentType    = "Content-Type"
+	appJSON        = "application/json; charset=utf-8"
+	appProblemJSON = "application/problem+json; charset=utf-8"
+
+	// RequestIDHeader is the header used to correlate the requests with
+	// the error responses and logs
+	RequestIDHeader = "X-Request-ID"
+
+	// problemTypeBlank is used as the problem type, the code and HTTP
+	// status describe the problem
+	problemTypeBlank = "about:blank"
+)
+
+// Stable error codes returned in the error responses
+const (
+	CodeBadRequest      = "bad_request"
+	CodeInvalidArgument = "invalid_argument"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeRequestTooLarge = "request_too_large"
+	CodeTooManyRequests = "too_many_requests"
+	CodeInternalError   = "internal_error"
+	CodeUnavailable     = "unavailable"
 )
 
+// Problem is the error response body as defined by RFC 7807
+// (https://www.rfc-editor.org/rfc/rfc7807) extended by the error code,
+// request ID and optional error specific details
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail"`
+	Code      string                 `json:"code"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// NewProblem constructs the error response body for given HTTP status
+func NewProblem(statusCode int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypeBlank,
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
 // setDefaultContentType is a helper function to set the Content-Type header
 func setDefaultContentType(w http.ResponseWriter) {
 	w.Header().Set(contentType, appJSON)
@@ -69,6 +116,17 @@ func Send(statusCode int, w http.ResponseWriter, data interface{}) error {
 	return json.NewEncoder(w).Encode(data)
 }
 
+// SendProblem sends the error response as application/problem+json. The
+// request ID is taken from the response headers when it's not set.
+func SendProblem(w http.ResponseWriter, problem *Problem) error {
+	if problem.RequestID == "" {
+		problem.RequestID = w.Header().Get(RequestIDHeader)
+	}
+	w.Header().Set(contentType, appProblemJSON)
+	w.WriteHeader(problem.Status)
+	return json.NewEncoder(w).Encode(problem)
+}
+
 // SendOK returns JSON response with status OK 200
 func SendOK(w http.ResponseWriter, data map[string]interface{}) error {
 	return Send(http.StatusOK, w, data)
@@ -86,25 +144,40 @@ func SendAccepted(w http.ResponseWriter, data map[string]interface{}) error {
 
 // SendBadRequest returns error response with status Bad Request 400
 func SendBadRequest(w http.ResponseWriter, errorMessage string) error {
-	return Send(http.StatusBadRequest, w, errorMessage)
+	return SendProblem(w, NewProblem(http.StatusBadRequest, CodeBadRequest, errorMessage))
 }
 
 // SendUnauthorized returns error response for unauthorized access with status Unauthorized 401
 func SendUnauthorized(w http.ResponseWriter, errorMessage string) error {
-	return Send(http.StatusUnauthorized, w, errorMessage)
+	return SendProblem(w, NewProblem(http.StatusUnauthorized, CodeUnauthorized, errorMessage))
 }
 
 // SendForbidden returns response with status Forbidden 403
 func SendForbidden(w http.ResponseWriter, errorMessage string) error {
-	return Send(http.StatusForbidden, w, errorMessage)
+	return SendProblem(w, NewProblem(http.StatusForbidden, CodeForbidden, errorMessage))
 }
 
 // SendNotFound returns response with status Not Found 404
 func SendNotFound(w http.ResponseWriter, errorMessage string) error {
-	return Send(http.StatusNotFound, w, errorMessage)
+	return SendProblem(w, NewProblem(http.StatusNotFound, CodeNotFound, errorMessage))
+}
+
+// SendRequestEntityTooLarge returns response with status Request Entity Too Large 413
+func SendRequestEntityTooLarge(w http.ResponseWriter, errorMessage string) error {
+	return SendProblem(w, NewProblem(http.StatusRequestEntityTooLarge, CodeRequestTooLarge, errorMessage))
+}
+
+// SendTooManyRequests returns response with status Too Many Requests 429
+func SendTooManyRequests(w http.ResponseWriter, errorMessage string) error {
+	return SendProblem(w, NewProblem(http.StatusTooManyRequests, CodeTooManyRequests, errorMessage))
+}
+
+// SendServiceUnavailable returns response with status Service Unavailable 503
+func SendServiceUnavailable(w http.ResponseWriter, errorMessage string) error {
+	return SendProblem(w, NewProblem(http.StatusServiceUnavailable, CodeUnavailable, errorMessage))
 }
 
 // SendInternalServerError returns response with status Internal Server Error 500
 func SendInternalServerError(w http.ResponseWriter, errorMessage string) error {
-	return Send(http.StatusInternalServerError, w, errorMessage)
+	return SendProblem(w, NewProblem(http.StatusInternalServerError, CodeInternalError, errorMessage))
 }
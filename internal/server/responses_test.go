@@ -76,6 +76,9 @@ var headerTestsWithoutData = []struct {
 	{"responses.SendForbidden", responses.SendForbidden, http.StatusForbidden},
 	{"responses.SendForbidden", responses.SendForbidden, http.StatusForbidden},
 	{"responses.SendNotFound", responses.SendNotFound, http.StatusNotFound},
+	{"responses.SendRequestEntityTooLarge", responses.SendRequestEntityTooLarge, http.StatusRequestEntityTooLarge},
+	{"responses.SendTooManyRequests", responses.SendTooManyRequests, http.StatusTooManyRequests},
+	{"responses.SendServiceUnavailable", responses.SendServiceUnavailable, http.StatusServiceUnavailable},
 	{"responses.SendInternalServerError", responses.SendInternalServerError, http.StatusInternalServerError},
 }
 
@@ -21,6 +21,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -31,14 +33,87 @@ import (
 
 const (
 	openAPIURL = "/openapi.json"
+
+	internalServerErrorMessage = "Internal Server Error"
+)
+
+// Default values used when the respective option is not configured
+const (
+	DefaultReadHeaderTimeout   = 5 * time.Second
+	DefaultReadTimeout         = 30 * time.Second
+	DefaultWriteTimeout        = 30 * time.Second
+	DefaultIdleTimeout         = 120 * time.Second
+	DefaultShutdownTimeout     = 5 * time.Second
+	DefaultMaxHeaderBytes      = http.DefaultMaxHeaderBytes
+	DefaultMaxRequestBodyBytes = 1 << 20
 )
 
 // Config data structure represents HTTP/HTTPS server configuration.
+// Timeouts and size limits with zero value are replaced by the defaults.
 type Config struct {
-	Address    string `mapstructure:"address" toml:"address"`
-	UseHTTPS   bool   `mapstructure:"use_https" toml:"use_https"`
-	EnableCORS bool   `mapstructure:"enable_cors" toml:"enable_cors"`
-	CertFolder string // added for testing purposes
+	Address             string          `mapstructure:"address" toml:"address"`
+	UseHTTPS            bool            `mapstructure:"use_https" toml:"use_https"`
+	EnableCORS          bool            `mapstructure:"enable_cors" toml:"enable_cors"`
+	ReadHeaderTimeout   time.Duration   `mapstructure:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout         time.Duration   `mapstructure:"read_timeout" toml:"read_timeout"`
+	WriteTimeout        time.Duration   `mapstructure:"write_timeout" toml:"write_timeout"`
+	IdleTimeout         time.Duration   `mapstructure:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout     time.Duration   `mapstructure:"shutdown_timeout" toml:"shutdown_timeout"`
+	MaxHeaderBytes      int             `mapstructure:"max_header_bytes" toml:"max_header_bytes"`
+	MaxRequestBodyBytes int64           `mapstructure:"max_request_body_bytes" toml:"max_request_body_bytes"`
+	TLS                 TLSConfig       `mapstructure:"tls" toml:"tls"`
+	RateLimit           RateLimitConfig `mapstructure:"rate_limit" toml:"rate_limit"`
+	CORS                CORSConfig      `mapstructure:"cors" toml:"cors"`
+	CertFolder          string          // added for testing purposes
+}
+
+func durationOrDefault(value, defaultValue time.Duration) time.Duration {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// GracefulShutdownTimeout returns how long the server waits for the
+// in-flight requests to finish when it is stopped
+func (cfg Config) GracefulShutdownTimeout() time.Duration {
+	return durationOrDefault(cfg.ShutdownTimeout, DefaultShutdownTimeout)
+}
+
+// requestBodyLimit returns the maximum accepted size of a request body
+func (cfg Config) requestBodyLimit() int64 {
+	if cfg.MaxRequestBodyBytes <= 0 {
+		return DefaultMaxRequestBodyBytes
+	}
+	return cfg.MaxRequestBodyBytes
+}
+
+// newHTTPServer constructs http.Server with the configured timeouts. The
+// CORS handler wraps the whole router, because the router doesn't run its
+// middlewares for the preflight requests of the routes without OPTIONS
+// method.
+func (cfg Config) newHTTPServer(handler http.Handler) (*http.Server, error) {
+	if cfg.EnableCORS {
+		corsMiddleware, err := CORSMiddleware(cfg.CORS)
+		if err != nil {
+			return nil, err
+		}
+		handler = corsMiddleware(handler)
+	}
+
+	maxHeaderBytes := cfg.MaxHeaderBytes
+	if maxHeaderBytes <= 0 {
+		maxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	return &http.Server{
+		Addr:              cfg.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: durationOrDefault(cfg.ReadHeaderTimeout, DefaultReadHeaderTimeout),
+		ReadTimeout:       durationOrDefault(cfg.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout:      durationOrDefault(cfg.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:       durationOrDefault(cfg.IdleTimeout, DefaultIdleTimeout),
+		MaxHeaderBytes:    maxHeaderBytes,
+	}, nil
 }
 
 // AuthConfig structure represents auth. settings for the server
@@ -53,6 +128,16 @@ type Server struct {
 	AuthConfig AuthConfig
 	Router     *mux.Router
 	HTTPServer *http.Server
+	// RateLimitKey identifies the client for rate limiting purposes
+	RateLimitKey KeyFunc
+	// NoAuthURLs are request URIs served without authentication, for
+	// example the health probes
+	NoAuthURLs []string
+
+	// mutex guards HTTPServer and certReloader, they're set by Start and
+	// read by Stop from another goroutine
+	mutex        sync.Mutex
+	certReloader *certificateReloader
 }
 
 // New function constructs new server instance.
@@ -71,20 +156,36 @@ func (server *Server) Start() error {
 	addr := server.Config.Address
 	log.Info().Msgf("Starting HTTP server at '%s'", addr)
 
-	if server.Config.EnableCORS {
-		server.Router.Use(CORSMiddleware())
+	// the request ID and access log come first, so every response, including
+	// the ones of the other middlewares, can be correlated with the logs.
+	// Panics are recovered as the next step, so any failure in the other
+	// middlewares is turned into an error response too
+	server.Router.Use(TracingMiddleware)
+	server.Router.Use(RequestIDMiddleware)
+	server.Router.Use(AccessLogMiddleware)
+	server.Router.Use(RecoveryMiddleware)
+	server.Router.Use(BodyLimitMiddleware(server.Config.requestBodyLimit()))
+
+	if server.Config.RateLimit.Enabled {
+		log.Info().
+			Float64("requestsPerSecond", server.Config.RateLimit.RequestsPerSecond).
+			Int("burst", server.Config.RateLimit.Burst).
+			Msg("Enabling rate limiting")
+		server.Router.Use(NewRateLimiter(server.Config.RateLimit, server.RateLimitKey).Middleware)
 	}
 
 	if server.AuthConfig.Enabled {
 		log.Info().Str("type", server.AuthConfig.Type).Msg("Enabling auth")
-		// we have to enable authentication for all endpoints, including endpoints
-		// for Prometheus metrics and OpenAPI specification, because there is not
-		// single prefix of other REST API calls. The special endpoints needs to
-		// be handled in middleware which is not optimal
+		// we have to enable authentication for all endpoints, including the
+		// OpenAPI specification, because there is not single prefix of other
+		// REST API calls. The special endpoints needs to be handled in
+		// middleware which is not optimal. Prometheus metrics should be
+		// served by the admin listener with its own auth. settings.
 		noAuthURLs := []string{
 			openAPIURL,
 			openAPIURL + "?", // to be able to test using Frisby
 		}
+		noAuthURLs = append(noAuthURLs, server.NoAuthURLs...)
 		server.Router.Use(func(next http.Handler) http.Handler {
 			return server.Authentication(next, noAuthURLs)
 		})
@@ -92,21 +193,37 @@ func (server *Server) Start() error {
 		log.Info().Msg("Auth disabled")
 	}
 
-	server.HTTPServer = &http.Server{
-		Addr:              addr,
-		Handler:           server.Router,
-		ReadHeaderTimeout: 5 * time.Second,
+	httpServer, err := server.Config.newHTTPServer(server.Router)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid CORS configuration")
+		return err
 	}
 
+	// the TLS configuration is built before the server is published, so
+	// Stop never sees a half configured server
+	var certReloader *certificateReloader
 	if server.Config.UseHTTPS {
 		log.Info().
 			Str("cert.folder", server.Config.CertFolder).
+			Str("client.auth", server.Config.TLS.ClientAuth).
 			Msg("Using TLS")
-		err = server.HTTPServer.ListenAndServeTLS(
-			server.Config.CertFolder+"server.crt",
-			server.Config.CertFolder+"server.key")
+		httpServer.TLSConfig, certReloader, err = server.buildTLSConfig()
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to configure TLS")
+			return err
+		}
+	}
+
+	server.mutex.Lock()
+	server.HTTPServer = httpServer
+	server.certReloader = certReloader
+	server.mutex.Unlock()
+
+	if server.Config.UseHTTPS {
+		// the key pair is provided by TLSConfig.GetCertificate
+		err = httpServer.ListenAndServeTLS("", "")
 	} else {
-		err = server.HTTPServer.ListenAndServe()
+		err = httpServer.ListenAndServe()
 	}
 
 	if err != nil && err != http.ErrServerClosed {
@@ -119,38 +236,101 @@ func (server *Server) Start() error {
 
 // Stop method stops server's execution.
 func (server *Server) Stop(ctx context.Context) error {
-	return server.HTTPServer.Shutdown(ctx)
+	server.mutex.Lock()
+	httpServer, certReloader := server.HTTPServer, server.certReloader
+	server.mutex.Unlock()
+
+	if certReloader != nil {
+		if err := certReloader.Close(); err != nil {
+			log.Error().Err(err).Msg("Unable to stop TLS certificate watcher")
+		}
+	}
+	if httpServer == nil {
+		// server has not been started
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
 }
 
-// HandleServerError handles separate server errors and sends appropriate responses
+// HandleServerError handles separate server errors and sends appropriate
+// responses with stable error code and details
 func HandleServerError(writer http.ResponseWriter, err error) {
-	logFunc := log.Error
+	problem := problemForError(err)
+
+	logFunc := log.Warn
+	if problem.Status >= http.StatusInternalServerError {
+		logFunc = log.Error
+	}
+	logFunc().
+		Type("errType", err).
+		Err(err).
+		Int("status", problem.Status).
+		Str("requestID", writer.Header().Get(RequestIDHeader)).
+		Msg("handleServerError()")
+
+	if respErr := SendProblem(writer, problem); respErr != nil {
+		log.Error().Err(respErr).Msg(errors.ResponseDataError)
+	}
+}
 
-	var respErr error
+// problemForError converts the error to the error response body. Errors of
+// unknown types are not described to the client.
+func problemForError(err error) *Problem {
+	var problem *Problem
 
 	switch err := err.(type) {
-	case *errors.RouterMissingParamError, *errors.RouterParsingError, *errors.NoBodyError, *errors.ValidationError:
-		respErr = SendBadRequest(writer, err.Error())
-		logFunc = log.Warn
+	case *errors.RouterMissingParamError:
+		problem = NewProblem(http.StatusBadRequest, CodeInvalidArgument, err.Error())
+		problem.Details = map[string]interface{}{"param": err.ParamName}
+	case *errors.RouterParsingError:
+		problem = NewProblem(http.StatusBadRequest, CodeInvalidArgument, err.Error())
+		problem.Details = map[string]interface{}{"param": err.ParamName}
+	case *errors.ValidationError:
+		problem = NewProblem(http.StatusBadRequest, CodeInvalidArgument, err.Error())
+		problem.Details = map[string]interface{}{"param": err.ParamName}
+	case *errors.NoBodyError:
+		problem = NewProblem(http.StatusBadRequest, CodeBadRequest, err.Error())
+	case *errors.InvalidBodyError:
+		problem = NewProblem(http.StatusBadRequest, CodeBadRequest, err.Error())
 	case *errors.NotFoundError:
-		respErr = SendNotFound(writer, err.Error())
-		logFunc = log.Warn
-	case *json.UnmarshalTypeError:
-		respErr = SendBadRequest(writer, "bad type in json data")
-		logFunc = log.Warn
+		problem = NewProblem(http.StatusNotFound, CodeNotFound, err.Error())
+	case *errors.ConflictError:
+		problem = NewProblem(http.StatusConflict, CodeConflict, err.Error())
+	case *errors.StorageNotFoundError:
+		// the full path is not disclosed to the client
+		problem = NewProblem(http.StatusNotFound, CodeNotFound, "store data not found")
+		problem.Details = map[string]interface{}{"resource": filepath.Base(err.Path)}
+	case *errors.StorageIOError:
+		problem = NewProblem(http.StatusServiceUnavailable, CodeUnavailable, "store data can't be read")
+	case *errors.CorruptDataError, *json.UnmarshalTypeError, *json.SyntaxError:
+		// the stored data are broken, it's not a problem of the client
+		problem = NewProblem(http.StatusInternalServerError, CodeInternalError, internalServerErrorMessage)
+	case *errors.Error:
+		problem = problemForErrorCode(err)
 	case *errors.UnauthorizedError:
-		respErr = SendUnauthorized(writer, err.Error())
-		logFunc = log.Warn
+		problem = NewProblem(http.StatusUnauthorized, CodeUnauthorized, err.Error())
 	case *errors.ForbiddenError:
-		respErr = SendForbidden(writer, err.Error())
-		logFunc = log.Warn
+		problem = NewProblem(http.StatusForbidden, CodeForbidden, err.Error())
+	case *http.MaxBytesError:
+		problem = NewProblem(http.StatusRequestEntityTooLarge, CodeRequestTooLarge, err.Error())
+		problem.Details = map[string]interface{}{"limit": err.Limit}
+	case *errors.TooManyRequestsError:
+		problem = NewProblem(http.StatusTooManyRequests, CodeTooManyRequests, err.Error())
 	default:
-		respErr = SendInternalServerError(writer, "Internal Server Error")
+		problem = NewProblem(http.StatusInternalServerError, CodeInternalError, internalServerErrorMessage)
 	}
 
-	logFunc().Type("errType", err).Err(err).Msg("handleServerError()")
+	return problem
+}
 
-	if respErr != nil {
-		log.Error().Err(respErr).Msg(errors.ResponseDataError)
+// problemForErrorCode maps the numeric code of *errors.Error to HTTP status
+func problemForErrorCode(err *errors.Error) *Problem {
+	switch err.Code() {
+	case errors.ErrorCodeNotFound:
+		return NewProblem(http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.ErrorCodeInvalidArgument:
+		return NewProblem(http.StatusBadRequest, CodeInvalidArgument, err.Error())
+	default:
+		return NewProblem(http.StatusInternalServerError, CodeInternalError, internalServerErrorMessage)
 	}
 }
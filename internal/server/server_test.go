@@ -18,12 +18,19 @@ package server_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type testCase struct {
@@ -50,12 +57,31 @@ func TestServer(t *testing.T) {
 			config: server.Config{
 				Address:    "localhost:1234",
 				EnableCORS: true,
+				CORS: server.CORSConfig{
+					AllowedOrigins: []string{"https://console.redhat.com"},
+				},
 			},
 			authConfig: server.AuthConfig{
 				Enabled: false,
 				Type:    "",
 			},
 		},
+		{
+			name: "with invalid CORS policy",
+			config: server.Config{
+				Address:    "localhost:1234",
+				EnableCORS: true,
+				CORS: server.CORSConfig{
+					AllowedOrigins:   []string{"*"},
+					AllowCredentials: true,
+				},
+			},
+			authConfig: server.AuthConfig{
+				Enabled: false,
+				Type:    "",
+			},
+			expectAnError: true,
+		},
 		{
 			name: "with TLS",
 			config: server.Config{
@@ -100,3 +126,189 @@ func TestServer(t *testing.T) {
 		})
 	}
 }
+
+// TestServerTimeouts checks that configured timeouts are used and the
+// defaults are applied to the missing ones
+func TestServerTimeouts(t *testing.T) {
+	httpServer, err := server.NewHTTPServer(server.Config{
+		Address:        "localhost:1234",
+		ReadTimeout:    10 * time.Second,
+		MaxHeaderBytes: 4096,
+	}, mux.NewRouter())
+	require.NoError(t, err)
+
+	assert.Equal(t, "localhost:1234", httpServer.Addr)
+	assert.Equal(t, 10*time.Second, httpServer.ReadTimeout)
+	assert.Equal(t, 4096, httpServer.MaxHeaderBytes)
+	assert.Equal(t, server.DefaultReadHeaderTimeout, httpServer.ReadHeaderTimeout)
+	assert.Equal(t, server.DefaultWriteTimeout, httpServer.WriteTimeout)
+	assert.Equal(t, server.DefaultIdleTimeout, httpServer.IdleTimeout)
+}
+
+// TestGracefulShutdownTimeout checks the default shutdown timeout
+func TestGracefulShutdownTimeout(t *testing.T) {
+	assert.Equal(t, server.DefaultShutdownTimeout, server.Config{}.GracefulShutdownTimeout())
+	assert.Equal(t, time.Minute, server.Config{ShutdownTimeout: time.Minute}.GracefulShutdownTimeout())
+}
+
+// TestServerRecoversFromPanic checks that the recovery middleware is used by
+// the server
+func TestServerRecoversFromPanic(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc(testedEndpoint, func(_ http.ResponseWriter, _ *http.Request) {
+		panic("handler failure")
+	})
+	testServer := server.New(serverConfig, server.AuthConfig{}, router)
+	go func() {
+		err := testServer.Start()
+		assert.NoError(t, err)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get(testedURL) // #nosec G107 -- test server URL
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	}
+
+	err = testServer.Stop(context.TODO())
+	assert.NoError(t, err)
+}
+
+// TestHandleServerError checks that errors are turned into problem+json
+// responses with stable error codes
+func TestHandleServerError(t *testing.T) {
+	testCases := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedCode    string
+		expectedDetail  string
+		expectedDetails map[string]interface{}
+	}{
+		{
+			name:            "parsing error",
+			err:             &errors.RouterParsingError{ParamName: "ocpVersion", ParamValue: "x", ErrString: "invalid"},
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    server.CodeInvalidArgument,
+			expectedDetail:  "Error during parsing param 'ocpVersion' with value 'x'. Error: 'invalid'",
+			expectedDetails: map[string]interface{}{"param": "ocpVersion"},
+		},
+		{
+			name:           "invalid request body",
+			err:            &errors.InvalidBodyError{Err: fmt.Errorf("unexpected EOF")},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   server.CodeBadRequest,
+			expectedDetail: "request body can't be parsed: unexpected EOF",
+		},
+		{
+			name:           "not found error",
+			err:            &errors.NotFoundError{ErrString: "not found"},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   server.CodeNotFound,
+			expectedDetail: "not found",
+		},
+		{
+			name:           "error with not found code",
+			err:            errors.NewErrorf(errors.ErrorCodeNotFound, "rules not found"),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   server.CodeNotFound,
+			expectedDetail: "rules not found",
+		},
+		{
+			name:           "error with invalid argument code",
+			err:            errors.NewErrorf(errors.ErrorCodeInvalidArgument, "invalid version"),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   server.CodeInvalidArgument,
+			expectedDetail: "invalid version",
+		},
+		{
+			name:           "error with unknown code",
+			err:            errors.WrapErrorf(fmt.Errorf("disk failure"), errors.ErrorCodeUnknown, "unable to read"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   server.CodeInternalError,
+			expectedDetail: "Internal Server Error",
+		},
+		{
+			name:           "conflict",
+			err:            &errors.ConflictError{ErrString: "nothing to roll back"},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   server.CodeConflict,
+			expectedDetail: "nothing to roll back",
+		},
+		{
+			name:            "missing store data",
+			err:             &errors.StorageNotFoundError{Path: "/conditions/stable/rules.json"},
+			expectedStatus:  http.StatusNotFound,
+			expectedCode:    server.CodeNotFound,
+			expectedDetail:  "store data not found",
+			expectedDetails: map[string]interface{}{"resource": "rules.json"},
+		},
+		{
+			name:           "unreadable store data",
+			err:            &errors.StorageIOError{Path: "rules.json", Err: fmt.Errorf("permission denied")},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   server.CodeUnavailable,
+			expectedDetail: "store data can't be read",
+		},
+		{
+			name:           "corrupt store data",
+			err:            &errors.CorruptDataError{Path: "rules.json", Err: fmt.Errorf("unexpected end of JSON input")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   server.CodeInternalError,
+			expectedDetail: "Internal Server Error",
+		},
+		{
+			name:           "JSON type error",
+			err:            &json.UnmarshalTypeError{Value: "number", Type: reflect.TypeOf(""), Field: "version"},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   server.CodeInternalError,
+			expectedDetail: "Internal Server Error",
+		},
+		{
+			name:           "unauthorized error",
+			err:            &errors.UnauthorizedError{ErrString: "Missing auth. token"},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   server.CodeUnauthorized,
+			expectedDetail: "Missing auth. token",
+		},
+		{
+			name:            "too large body",
+			err:             &http.MaxBytesError{Limit: 10},
+			expectedStatus:  http.StatusRequestEntityTooLarge,
+			expectedCode:    server.CodeRequestTooLarge,
+			expectedDetail:  "http: request body too large",
+			expectedDetails: map[string]interface{}{"limit": float64(10)},
+		},
+		{
+			name:           "unknown error",
+			err:            fmt.Errorf("secret internal failure"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   server.CodeInternalError,
+			expectedDetail: "Internal Server Error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			rr.Header().Set(server.RequestIDHeader, "request-1")
+			server.HandleServerError(rr, tc.err)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, "application/problem+json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+			var problem server.Problem
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, server.Problem{
+				Type:      "about:blank",
+				Title:     http.StatusText(tc.expectedStatus),
+				Status:    tc.expectedStatus,
+				Detail:    tc.expectedDetail,
+				Code:      tc.expectedCode,
+				RequestID: "request-1",
+				Details:   tc.expectedDetails,
+			}, problem)
+		})
+	}
+}
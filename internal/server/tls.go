@@ -0,0 +1,229 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/files"
+)
+
+const (
+	certFileName = "server.crt"
+	keyFileName  = "server.key"
+
+	// ClientAuthNone disables client certificate verification
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies the client certificate if one is provided
+	ClientAuthOptional = "optional"
+	// ClientAuthRequired rejects clients without a valid certificate
+	ClientAuthRequired = "required"
+)
+
+// TLSConfig structure represents TLS settings used when use_https is enabled
+type TLSConfig struct {
+	MinVersion         string   `mapstructure:"min_version" toml:"min_version"`
+	CipherSuites       []string `mapstructure:"cipher_suites" toml:"cipher_suites"`
+	ClientCAFile       string   `mapstructure:"client_ca_file" toml:"client_ca_file"`
+	ClientAuth         string   `mapstructure:"client_auth" toml:"client_auth"`
+	ReloadCertificates bool     `mapstructure:"reload_certificates" toml:"reload_certificates"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSVersion converts version like "1.2" to its crypto/tls constant.
+// TLS 1.2 is used when no version is configured.
+func parseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return tls.VersionTLS12, nil
+	}
+	v, found := tlsVersions[strings.TrimPrefix(version, "TLS")]
+	if !found {
+		return 0, fmt.Errorf("unsupported TLS version '%s'", version)
+	}
+	return v, nil
+}
+
+// parseCipherSuites converts the IANA cipher suite names to their IDs. Only
+// the suites considered secure by crypto/tls are accepted.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, found := known[name]
+		if !found {
+			return nil, fmt.Errorf("unsupported cipher suite '%s'", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseClientAuth converts the configured client certificate mode
+func parseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequired:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unsupported client auth mode '%s'", mode)
+	}
+}
+
+// loadCertPool reads PEM encoded CA certificates from given file
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from service configuration
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in '%s'", path)
+	}
+	return pool, nil
+}
+
+// buildTLSConfig constructs the tls.Config for the HTTPS server with the
+// reloader of its key pair, which has to be closed when the server stops
+func (server *Server) buildTLSConfig() (*tls.Config, *certificateReloader, error) {
+	cfg := server.Config.TLS
+
+	minVersion, err := parseTLSVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cipherSuites, err := parseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientAuth, err := parseClientAuth(cfg.ClientAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reloader, err := newCertificateReloader(
+		filepath.Join(server.Config.CertFolder, certFileName),
+		filepath.Join(server.Config.CertFolder, keyFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   cipherSuites,
+		ClientAuth:     clientAuth,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if cfg.ClientCAFile != "" {
+		tlsConfig.ClientCAs, err = loadCertPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if clientAuth != tls.NoClientCert {
+		return nil, nil, fmt.Errorf("client auth mode '%s' requires client_ca_file", cfg.ClientAuth)
+	}
+
+	if cfg.ReloadCertificates {
+		err = reloader.Watch()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return tlsConfig, reloader, nil
+}
+
+// certificateReloader holds the server key pair and reloads it when the
+// files change on disk, so rotated certificates are used without a restart
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	mutex    sync.RWMutex
+	cert     *tls.Certificate
+	watcher  *files.Watcher
+}
+
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// Reload reads the key pair from disk again
+func (r *certificateReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	r.cert = &cert
+	r.mutex.Unlock()
+	return nil
+}
+
+// GetCertificate returns the last successfully loaded certificate
+func (r *certificateReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.cert, nil
+}
+
+// Watch starts reloading the key pair when its files change
+func (r *certificateReloader) Watch() error {
+	watcher, err := files.WatchDirs("TLS certificate",
+		[]string{filepath.Dir(r.certFile), filepath.Dir(r.keyFile)}, r.Reload)
+	if err != nil {
+		return err
+	}
+	r.watcher = watcher
+	return nil
+}
+
+// Close stops watching the key pair files
+func (r *certificateReloader) Close() error {
+	return r.watcher.Close()
+}
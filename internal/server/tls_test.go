@@ -0,0 +1,252 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+)
+
+const tlsTestAddress = "localhost:1235"
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCertificate generates certificate signed by parent (self-signed
+// when parent is nil)
+func newTestCertificate(t *testing.T, commonName string, serial int64, parent *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Red Hat"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeKeyPair(t *testing.T, dir string, c *testCertificate) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.crt"), c.certPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.key"), c.keyPEM, 0o600))
+}
+
+func startTLSServer(t *testing.T, cfg server.Config, authCfg server.AuthConfig, router *mux.Router) *server.Server {
+	t.Helper()
+	testServer := server.New(cfg, authCfg, router)
+	go func() {
+		err := testServer.Start()
+		assert.NoError(t, err)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	return testServer
+}
+
+func tlsClient(ca *testCertificate, clientCert *testCertificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	tlsConfig := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{{
+			Certificate: [][]byte{clientCert.cert.Raw},
+			PrivateKey:  clientCert.key,
+		}}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+}
+
+// TestTLSConfigValidation checks that invalid TLS settings prevent the server
+// from starting
+func TestTLSConfigValidation(t *testing.T) {
+	testCases := []struct {
+		name string
+		tls  server.TLSConfig
+	}{
+		{
+			name: "unknown TLS version",
+			tls:  server.TLSConfig{MinVersion: "0.9"},
+		},
+		{
+			name: "unknown cipher suite",
+			tls:  server.TLSConfig{CipherSuites: []string{"TLS_NOT_A_CIPHER"}},
+		},
+		{
+			name: "unknown client auth mode",
+			tls:  server.TLSConfig{ClientAuth: "sometimes"},
+		},
+		{
+			name: "client auth without CA bundle",
+			tls:  server.TLSConfig{ClientAuth: server.ClientAuthRequired},
+		},
+		{
+			name: "CA bundle not found",
+			tls:  server.TLSConfig{ClientAuth: server.ClientAuthOptional, ClientCAFile: "not-a-file.pem"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testServer := server.New(server.Config{
+				Address:    tlsTestAddress,
+				UseHTTPS:   true,
+				CertFolder: "testdata/",
+				TLS:        tc.tls,
+			}, server.AuthConfig{}, mux.NewRouter())
+			assert.Error(t, testServer.Start())
+		})
+	}
+}
+
+// TestMutualTLS checks the client certificate verification and that the
+// certificate subject is used as the identity by the auth. middleware
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "test CA", 1, nil)
+	writeKeyPair(t, dir, newTestCertificate(t, "localhost", 2, ca))
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, ca.certPEM, 0o600))
+
+	router := mux.NewRouter()
+	router.HandleFunc(testedEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		identity, err := (&server.Server{}).GetAuthToken(r)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(identity.AccountNumber))
+		assert.NoError(t, err)
+	})
+
+	testServer := startTLSServer(t, server.Config{
+		Address:    tlsTestAddress,
+		UseHTTPS:   true,
+		CertFolder: dir,
+		TLS: server.TLSConfig{
+			MinVersion:   "1.2",
+			ClientCAFile: caFile,
+			ClientAuth:   server.ClientAuthRequired,
+		},
+	}, server.AuthConfig{Enabled: true, Type: "mtls"}, router)
+	defer func() {
+		assert.NoError(t, testServer.Stop(context.TODO()))
+	}()
+
+	url := "https://" + tlsTestAddress + testedEndpoint
+
+	t.Run("client without certificate is rejected", func(t *testing.T) {
+		_, err := tlsClient(ca, nil).Get(url) // #nosec G107 -- test server URL
+		assert.Error(t, err)
+	})
+
+	t.Run("client with certificate from unknown CA is rejected", func(t *testing.T) {
+		other := newTestCertificate(t, "other CA", 3, nil)
+		_, err := tlsClient(ca, newTestCertificate(t, "cluster", 4, other)).Get(url) // #nosec G107 -- test server URL
+		assert.Error(t, err)
+	})
+
+	t.Run("client with valid certificate is accepted", func(t *testing.T) {
+		resp, err := tlsClient(ca, newTestCertificate(t, "cluster", 5, ca)).Get(url) // #nosec G107 -- test server URL
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "cluster", string(body))
+	})
+}
+
+// TestCertificateReload checks that the server starts serving a new key
+// pair after the files are replaced on disk
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "test CA", 1, nil)
+	writeKeyPair(t, dir, newTestCertificate(t, "localhost", 10, ca))
+
+	router := mux.NewRouter()
+	router.HandleFunc(testedEndpoint, dummyHandler)
+	testServer := startTLSServer(t, server.Config{
+		Address:    tlsTestAddress,
+		UseHTTPS:   true,
+		CertFolder: dir,
+		TLS:        server.TLSConfig{ReloadCertificates: true},
+	}, server.AuthConfig{}, router)
+	defer func() {
+		assert.NoError(t, testServer.Stop(context.TODO()))
+	}()
+
+	servedSerial := func() int64 {
+		client := tlsClient(ca, nil)
+		client.Transport.(*http.Transport).DisableKeepAlives = true
+		resp, err := client.Get("https://" + tlsTestAddress + testedEndpoint) // #nosec G107 -- test server URL
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	assert.Equal(t, int64(10), servedSerial())
+
+	writeKeyPair(t, dir, newTestCertificate(t, "localhost", 11, ca))
+	assert.Eventually(t, func() bool {
+		return servedSerial() == 11
+	}, 2*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"time"
+)
+
+// Kinds of the entries with activation window
+const (
+	EntryKindRule            = "rule"
+	EntryKindConditionalRule = "conditional_gathering_rule"
+	EntryKindContainerLogs   = "container_logs"
+)
+
+// Clock returns the current time. It's replaced by a fixed time in tests.
+type Clock func() time.Time
+
+// Activation structure represents the optional time window of a rule or a
+// container logs request. The entry is served only inside of the window. The
+// window is not sent to the clients.
+type Activation struct {
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+}
+
+// IsActive returns true when the time is inside of the window
+func (a Activation) IsActive(now time.Time) bool {
+	return (a.ActiveFrom == nil || !now.Before(*a.ActiveFrom)) && !a.IsExpired(now)
+}
+
+// IsExpired returns true when the window ended before the time
+func (a Activation) IsExpired(now time.Time) bool {
+	return a.ActiveUntil != nil && !now.Before(*a.ActiveUntil)
+}
+
+// ExpiredEntry structure describes a stored entry whose activation window
+// has ended
+type ExpiredEntry struct {
+	// Source is the file or the overlay with the entry
+	Source      string    `json:"source"`
+	Kind        string    `json:"kind"`
+	Index       int       `json:"index"`
+	ActiveUntil time.Time `json:"active_until"`
+	// RuleID identifies the expired rule, it's empty for the other kinds
+	RuleID string `json:"rule_id,omitempty"`
+}
+
+// ExpiredEntriesResponse structure represents HTTP response with the
+// expired entries
+type ExpiredEntriesResponse struct {
+	Expired []ExpiredEntry `json:"expired"`
+}
+
+// filterActive returns the active items without their activation windows
+// and the number of the expired items
+func filterActive[T any](items []T, now time.Time, activation func(*T) *Activation) ([]T, int) {
+	if items == nil {
+		return nil, 0
+	}
+
+	active := make([]T, 0, len(items))
+	expired := 0
+	for _, item := range items {
+		window := activation(&item)
+		if window.IsExpired(now) {
+			expired++
+			continue
+		}
+		if !window.IsActive(now) {
+			continue
+		}
+		*window = Activation{}
+		active = append(active, item)
+	}
+	return active, expired
+}
+
+// findExpired returns the expired items of the source
+func findExpired[T any](items []T, now time.Time, source, kind string, activation func(*T) *Activation) []ExpiredEntry {
+	var expired []ExpiredEntry
+	for i := range items {
+		if window := activation(&items[i]); window.IsExpired(now) {
+			entry := ExpiredEntry{
+				Source:      source,
+				Kind:        kind,
+				Index:       i,
+				ActiveUntil: *window.ActiveUntil,
+			}
+			if rule, ok := any(&items[i]).(*Rule); ok {
+				entry.RuleID = rule.RuleID()
+			}
+			expired = append(expired, entry)
+		}
+	}
+	return expired
+}
+
+func ruleActivation(rule *Rule) *Activation {
+	return &rule.Activation
+}
+
+func containerLogsActivation(request *ContainerLogRequest) *Activation {
+	return &request.Activation
+}
+
+// activeRemoteConfiguration removes the inactive entries from the remote
+// configuration and returns the number of the expired ones
+func activeRemoteConfiguration(config *RemoteConfiguration, now time.Time) int {
+	var expiredRules, expiredLogs int
+	config.ConditionalRules, expiredRules = filterActive(config.ConditionalRules, now, ruleActivation)
+	config.ContainerLogsRequests, expiredLogs = filterActive(config.ContainerLogsRequests, now, containerLogsActivation)
+	return expiredRules + expiredLogs
+}
+
+// expiredInRemoteConfiguration returns the expired entries of the remote
+// configuration
+func expiredInRemoteConfiguration(config *RemoteConfiguration, now time.Time, source string) []ExpiredEntry {
+	return append(
+		findExpired(config.ConditionalRules, now, source, EntryKindConditionalRule, ruleActivation),
+		findExpired(config.ContainerLogsRequests, now, source, EntryKindContainerLogs, containerLogsActivation)...,
+	)
+}
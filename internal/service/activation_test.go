@@ -0,0 +1,167 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+var activationNow = time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+const (
+	activationRulesJSON = `{
+		"version": "0.0.1",
+		"rules": [
+			{"conditions": ["always"]},
+			{"conditions": ["expired"], "active_until": "2026-02-01T00:00:00Z"},
+			{"conditions": ["future"], "active_from": "2026-04-01T00:00:00Z"},
+			{"conditions": ["window"], "active_from": "2026-02-01T00:00:00Z", "active_until": "2026-04-01T00:00:00Z"}
+		]
+	}`
+	activationRemoteConfigJSON = `{
+		"version": "1.1.0",
+		"conditional_gathering_rules": [
+			{"conditions": ["expired"], "active_until": "2026-03-01T12:00:00Z"},
+			{"conditions": ["active"], "active_until": "2026-03-01T12:00:01Z"}
+		],
+		"container_logs": [
+			{"namespace": "ns", "pod_name_regex": "expired", "messages": [], "active_until": "2026-01-01T00:00:00Z"},
+			{"namespace": "ns", "pod_name_regex": "active", "messages": [], "active_from": "2026-03-01T12:00:00Z"}
+		]
+	}`
+)
+
+func timeRef(t time.Time) *time.Time {
+	return &t
+}
+
+func TestActivation(t *testing.T) {
+	testCases := []struct {
+		name            string
+		activation      service.Activation
+		expectedActive  bool
+		expectedExpired bool
+	}{
+		{"no window", service.Activation{}, true, false},
+		{"not yet active", service.Activation{ActiveFrom: timeRef(activationNow.Add(time.Hour))}, false, false},
+		{"active from now", service.Activation{ActiveFrom: timeRef(activationNow)}, true, false},
+		{"active until later", service.Activation{ActiveUntil: timeRef(activationNow.Add(time.Hour))}, true, false},
+		{"expired now", service.Activation{ActiveUntil: timeRef(activationNow)}, false, true},
+		{
+			"inside of window",
+			service.Activation{ActiveFrom: timeRef(activationNow.Add(-time.Hour)), ActiveUntil: timeRef(activationNow.Add(time.Hour))},
+			true, false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedActive, tc.activation.IsActive(activationNow))
+			assert.Equal(t, tc.expectedExpired, tc.activation.IsExpired(activationNow))
+		})
+	}
+}
+
+func TestRepositoryDropsInactiveEntries(t *testing.T) {
+	store := mockStorage{
+		conditionalRules:     []byte(activationRulesJSON),
+		remoteConfig:         []byte(activationRemoteConfigJSON),
+		remoteConfigFilepath: "config.json",
+		overlays: []*service.Overlay{{
+			Name: "clusters/" + canaryClusterID,
+			ConditionalRules: []service.Rule{
+				{Conditions: []interface{}{"overlay expired"}, Activation: service.Activation{ActiveUntil: timeRef(activationNow)}},
+				{Conditions: []interface{}{"overlay"}},
+			},
+		}},
+	}
+	repo := service.NewRepository(&store)
+	repo.SetClock(func() time.Time { return activationNow })
+
+	req, err := http.NewRequest(http.MethodGet, "/gathering_rules", http.NoBody)
+	require.NoError(t, err)
+
+	rules, err := repo.Rules(req)
+	require.NoError(t, err)
+	data, err := json.Marshal(rules.Items)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"conditions":["always"]},{"conditions":["window"]}]`, string(data))
+	// the mock storage serves the canary channel
+	assert.Equal(t, 1.0, testutil.ToFloat64(service.ExpiredEntriesMetric.WithLabelValues("canary/rules.json")))
+
+	remoteConfig, err := repo.RemoteConfiguration(req, "4.17.0")
+	require.NoError(t, err)
+	data, err = json.Marshal(remoteConfig)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "1.1.0",
+		"conditional_gathering_rules": [{"conditions":["active"]}, {"conditions":["overlay"]}],
+		"container_logs": [{"namespace":"ns","pod_name_regex":"active","messages":[]}]
+	}`, string(data))
+	assert.Equal(t, 2.0, testutil.ToFloat64(service.ExpiredEntriesMetric.WithLabelValues("config.json")))
+
+	// the stored overlay is not changed
+	assert.Len(t, store.overlays[0].ConditionalRules, 2)
+}
+
+func TestExpiredEntriesEndpoint(t *testing.T) {
+	store := mockStorage{
+		conditionalRules:     []byte(activationRulesJSON),
+		remoteConfig:         []byte(activationRemoteConfigJSON),
+		remoteConfigFilepath: "config.json",
+		overlays: []*service.Overlay{{
+			Name: "orgs/123456",
+			ContainerLogsRequests: []service.ContainerLogRequest{
+				{Namespace: "ns", Activation: service.Activation{ActiveUntil: timeRef(activationNow.Add(-time.Hour))}},
+			},
+		}},
+	}
+	repo := service.NewRepository(&store)
+	repo.SetClock(func() time.Time { return activationNow })
+	router := mux.NewRouter()
+	service.NewHandler(service.New(repo)).RegisterAdmin(router)
+
+	req := httptest.NewRequest(http.MethodGet, service.ExpiredPath, http.NoBody)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"expired": [
+		{"source":"stable/rules.json","kind":"rule","index":1,"active_until":"2026-02-01T00:00:00Z","rule_id":"6d22bb68cd3c0cfd"},
+		{"source":"canary/rules.json","kind":"rule","index":1,"active_until":"2026-02-01T00:00:00Z","rule_id":"6d22bb68cd3c0cfd"},
+		{"source":"config.json","kind":"conditional_gathering_rule","index":0,"active_until":"2026-03-01T12:00:00Z","rule_id":"6d22bb68cd3c0cfd"},
+		{"source":"config.json","kind":"container_logs","index":0,"active_until":"2026-01-01T00:00:00Z"},
+		{"source":"orgs/123456","kind":"container_logs","index":0,"active_until":"2026-03-01T11:00:00Z"}
+	]}`, rr.Body.String())
+
+	store.readMockError = errors.New("read failed")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+)
+
+// Headers and query parameters declaring the capabilities of the client. The
+// query parameters take precedence over the headers.
+const (
+	SupportedFunctionsHeader  = "X-Supported-Gathering-Functions"
+	SupportedConditionsHeader = "X-Supported-Condition-Types"
+	SupportedFunctionParam    = "supported_gathering_function"
+	SupportedConditionParam   = "supported_condition_type"
+)
+
+// kinds of the unsupported rule parts
+const (
+	unsupportedGatheringFunction = "gathering_function"
+	unsupportedConditionType     = "condition_type"
+)
+
+// Capabilities structure represents the gathering functions and condition
+// types supported by the client. A nil list means the client didn't declare
+// it, so everything is supported.
+type Capabilities struct {
+	GatheringFunctions []string
+	ConditionTypes     []string
+}
+
+// ParseCapabilities reads the capabilities of the client from the query
+// parameters or the headers. The values can be repeated or separated by
+// commas.
+func ParseCapabilities(r *http.Request) Capabilities {
+	return Capabilities{
+		GatheringFunctions: capabilityValues(r, SupportedFunctionParam, SupportedFunctionsHeader),
+		ConditionTypes:     capabilityValues(r, SupportedConditionParam, SupportedConditionsHeader),
+	}
+}
+
+// capabilityValues returns the values of the query parameter or of the header
+// when the parameter is missing. It returns nil when both are missing.
+func capabilityValues(r *http.Request, param, header string) []string {
+	var query url.Values
+	if r.URL != nil {
+		query = r.URL.Query()
+	}
+	if _, found := query[param]; !found {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			return nil
+		}
+		query = url.Values{param: values}
+	}
+	return append([]string{}, queryValues(query, param)...)
+}
+
+// IsEmpty returns true when the client didn't declare any capability
+func (c Capabilities) IsEmpty() bool {
+	return c.GatheringFunctions == nil && c.ConditionTypes == nil
+}
+
+// Unsupported returns the sorted gathering functions and condition types of
+// the rule the client doesn't support
+func (c Capabilities) Unsupported(rule Rule) (functions, conditionTypes []string) {
+	if c.GatheringFunctions != nil {
+		for name := range fieldsOf(rule.GatheringFunctions) {
+			if !slices.Contains(c.GatheringFunctions, name) {
+				functions = append(functions, name)
+			}
+		}
+		sort.Strings(functions)
+	}
+
+	if c.ConditionTypes != nil {
+		for _, condition := range rule.Conditions {
+			conditionType, _ := fieldOf(condition, "type").(string)
+			if !slices.Contains(c.ConditionTypes, conditionType) && !slices.Contains(conditionTypes, conditionType) {
+				conditionTypes = append(conditionTypes, conditionType)
+			}
+		}
+		sort.Strings(conditionTypes)
+	}
+	return functions, conditionTypes
+}
+
+// supportedRules returns the rules supported by the client. When counted, the
+// stripped rules are counted by their unsupported gathering functions and
+// condition types.
+func (c Capabilities) supportedRules(rules []Rule, counted bool) (supported []Rule, stripped int) {
+	if c.IsEmpty() || rules == nil {
+		return rules, 0
+	}
+
+	supported = make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		functions, conditionTypes := c.Unsupported(rule)
+		if len(functions) == 0 && len(conditionTypes) == 0 {
+			supported = append(supported, rule)
+			continue
+		}
+
+		stripped++
+		if !counted {
+			continue
+		}
+		for _, name := range functions {
+			strippedRulesMetric.WithLabelValues(unsupportedGatheringFunction, name).Inc()
+		}
+		for _, name := range conditionTypes {
+			strippedRulesMetric.WithLabelValues(unsupportedConditionType, name).Inc()
+		}
+	}
+	return supported, stripped
+}
+
+// fieldsOf returns the fields of decoded JSON object or nil
+func fieldsOf(object interface{}) map[string]interface{} {
+	fields, _ := object.(map[string]interface{})
+	return fields
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		headers  map[string]string
+		expected service.Capabilities
+	}{
+		{
+			name:     "nothing declared",
+			url:      "/gathering_rules",
+			expected: service.Capabilities{},
+		},
+		{
+			name: "query parameters",
+			url:  "/gathering_rules?supported_gathering_function=containers_logs,pod_definition&supported_condition_type=alert_is_firing",
+			expected: service.Capabilities{
+				GatheringFunctions: []string{"containers_logs", "pod_definition"},
+				ConditionTypes:     []string{"alert_is_firing"},
+			},
+		},
+		{
+			name:     "headers",
+			url:      "/gathering_rules",
+			headers:  map[string]string{service.SupportedFunctionsHeader: "containers_logs, pod_definition"},
+			expected: service.Capabilities{GatheringFunctions: []string{"containers_logs", "pod_definition"}},
+		},
+		{
+			name:     "query parameter takes precedence",
+			url:      "/gathering_rules?supported_gathering_function=logs_of_namespace",
+			headers:  map[string]string{service.SupportedFunctionsHeader: "containers_logs"},
+			expected: service.Capabilities{GatheringFunctions: []string{"logs_of_namespace"}},
+		},
+		{
+			name:     "no condition type is supported",
+			url:      "/gathering_rules?supported_condition_type=",
+			expected: service.Capabilities{ConditionTypes: []string{}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, http.NoBody)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			assert.Equal(t, tc.expected, service.ParseCapabilities(req))
+		})
+	}
+}
+
+func TestCapabilitiesUnsupported(t *testing.T) {
+	rule := service.Rule{
+		Conditions: []interface{}{alertCondition("KubePodNotReady"), versionCondition(">=4.16.0")},
+		GatheringFunctions: map[string]interface{}{
+			"pod_definition":  map[string]interface{}{},
+			"containers_logs": map[string]interface{}{},
+		},
+	}
+
+	testCases := []struct {
+		name                   string
+		capabilities           service.Capabilities
+		expectedFunctions      []string
+		expectedConditionTypes []string
+	}{
+		{"nothing declared", service.Capabilities{}, nil, nil},
+		{
+			"everything is supported",
+			service.Capabilities{
+				GatheringFunctions: []string{"containers_logs", "pod_definition"},
+				ConditionTypes:     []string{service.AlertIsFiringCondition, service.ClusterVersionMatchesCondition},
+			},
+			nil, nil,
+		},
+		{
+			"unsupported gathering function",
+			service.Capabilities{GatheringFunctions: []string{"containers_logs"}},
+			[]string{"pod_definition"}, nil,
+		},
+		{
+			"unsupported condition types",
+			service.Capabilities{ConditionTypes: []string{}},
+			nil, []string{service.AlertIsFiringCondition, service.ClusterVersionMatchesCondition},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			functions, conditionTypes := tc.capabilities.Unsupported(rule)
+			assert.Equal(t, tc.expectedFunctions, functions)
+			assert.Equal(t, tc.expectedConditionTypes, conditionTypes)
+		})
+	}
+}
+
+// TestRepositoryStripsUnsupportedRules checks the rules with gathering
+// functions unsupported by the client are not served and counted
+func TestRepositoryStripsUnsupportedRules(t *testing.T) {
+	store := mockStorage{
+		remoteConfig:         []byte(configDefaultConfiguration),
+		remoteConfigFilepath: "config_default.json",
+	}
+	repo := service.NewRepository(&store)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/4.17.0/gathering_rules", http.NoBody)
+	req.Header.Set(service.SupportedFunctionsHeader, "containers_logs,pod_definition")
+	stripped := service.StrippedRulesMetric.WithLabelValues("gathering_function", "logs_of_namespace")
+	before := testutil.ToFloat64(stripped)
+
+	remoteConfig, err := repo.RemoteConfiguration(req, "4.17.0")
+	require.NoError(t, err)
+
+	// the rules of the APIRemovedInNextEUSReleaseInUse and
+	// SamplesImagestreamImportFailing alerts are stripped
+	assert.Len(t, remoteConfig.ConditionalRules, 7)
+	for _, rule := range remoteConfig.ConditionalRules {
+		assert.NotContains(t, rule.GatheringFunctions, "logs_of_namespace")
+		assert.NotContains(t, rule.GatheringFunctions, "api_request_counts_of_resource_from_alert")
+	}
+	assert.Equal(t, before+1, testutil.ToFloat64(stripped))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/blang/semver/v4"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// ChannelConfigFile is the optional file of a channel directory declaring
+// its parent channel
+const ChannelConfigFile = "channel.json"
+
+// ChannelConfig structure represents the configuration of a channel
+type ChannelConfig struct {
+	// Parent is the channel providing the cluster mapping entries and the
+	// files missing in this channel
+	Parent string `json:"parent,omitempty"`
+}
+
+// ConfigurationOrigin structure describes where the remote configuration
+// comes from
+type ConfigurationOrigin struct {
+	Channel string `json:"channel"`
+	File    string `json:"file"`
+	// InheritedFrom is the parent channel providing the file missing in the
+	// channel
+	InheritedFrom string `json:"inherited_from,omitempty"`
+}
+
+// newConfigurationOrigin returns the origin of the remote configuration read
+// from the file
+func newConfigurationOrigin(isCanary bool, file, inheritedFrom string) *ConfigurationOrigin {
+	channel := StableVersion
+	if isCanary {
+		channel = CanaryVersion
+	}
+	return &ConfigurationOrigin{
+		Channel:       channel,
+		File:          filepath.Base(file),
+		InheritedFrom: inheritedFrom,
+	}
+}
+
+// loadChannelConfig reads the configuration of the channel. A missing file
+// means the channel has no parent.
+func (s *Storage) loadChannelConfig(channel string, children []string) (ChannelConfig, error) {
+	var config ChannelConfig
+	path := filepath.Join(s.remoteConfigurationsPath, channel, ChannelConfigFile)
+	data, err := s.readDataFromPath(context.Background(), path)
+	var notFoundErr *merrors.StorageNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, &merrors.CorruptDataError{Path: path, Err: err}
+	}
+
+	switch {
+	case config.Parent == "":
+	case config.Parent != StableVersion && config.Parent != CanaryVersion:
+		return config, &merrors.CorruptDataError{Path: path, Err: fmt.Errorf("unknown parent channel '%s'", config.Parent)}
+	case config.Parent == channel || slices.Contains(children, config.Parent):
+		chain := append(slices.Clone(children), channel, config.Parent)
+		return config, &merrors.CorruptDataError{
+			Path: path,
+			Err:  fmt.Errorf("the channel parents make a cycle: %s", strings.Join(chain, " -> ")),
+		}
+	}
+	return config, nil
+}
+
+// mergeClusterMappings returns the entries of the child mapping with the
+// entries of the parent for the other versions, sorted by the versions
+func mergeClusterMappings(child, parent [][]string) [][]string {
+	merged := slices.Clone(child)
+	for _, entry := range parent {
+		if !slices.ContainsFunc(child, func(childEntry []string) bool {
+			return len(childEntry) > 0 && len(entry) > 0 && childEntry[0] == entry[0]
+		}) {
+			merged = append(merged, entry)
+		}
+	}
+
+	// the parsing errors are reported by the validation of the mappings
+	slices.SortStableFunc(merged, func(a, b []string) int {
+		versionA, errA := semver.Make(a[0])
+		versionB, errB := semver.Make(b[0])
+		if errA != nil || errB != nil {
+			return 0
+		}
+		return versionA.Compare(versionB)
+	})
+	return merged
+}
+
+// channelFilepath returns the path of the file following the parents of
+// its channel until the file exists, with the channel it's inherited from.
+// The path is returned as it is when it's not found in any channel.
+func (s *Storage) channelFilepath(path string) (string, string) {
+	if len(s.channelParents) == 0 {
+		return path, ""
+	}
+	relativePath, err := filepath.Rel(s.remoteConfigurationsPath, path)
+	if err != nil || !filepath.IsLocal(relativePath) {
+		return path, ""
+	}
+	channel, file, found := strings.Cut(relativePath, string(filepath.Separator))
+	if !found {
+		return path, ""
+	}
+
+	seen := map[string]bool{}
+	for current := channel; current != "" && !seen[current]; current = s.channelParents[current] {
+		seen[current] = true
+		candidate := filepath.Join(s.remoteConfigurationsPath, current, file)
+		if _, err := os.Stat(candidate); err == nil {
+			if current == channel {
+				return path, ""
+			}
+			return candidate, current
+		}
+	}
+	return path, ""
+}
+
+// InheritedFrom returns the parent channel providing the file missing in
+// its channel, or an empty string
+func (s *Storage) InheritedFrom(path string) string {
+	if channel, found := s.inheritedFiles.Load(path); found {
+		return channel.(string)
+	}
+	return ""
+}
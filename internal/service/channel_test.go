@@ -0,0 +1,197 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+const channelParentFolder = "testdata/v2_channel_parent"
+
+// TestStorageChannelParent checks the mapping entries and the files missing
+// in the canary channel are inherited from the stable one
+func TestStorageChannelParent(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: channelParentFolder,
+	}, true, &MockUnleashClient{})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		ocpVersion            string
+		expectedFilepath      string
+		expectedVersion       string
+		expectedInheritedFrom string
+	}{
+		{"4.12.0", channelParentFolder + "/canary/config_default.json", "1.0.0", service.StableVersion},
+		{"4.14.0", channelParentFolder + "/canary/experimental.json", "1.2.0-canary", ""},
+		{"4.17.0", channelParentFolder + "/canary/experimental.json", "1.2.0-canary", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ocpVersion, func(t *testing.T) {
+			path, err := storage.GetRemoteConfigurationFilepath(context.Background(), true, tc.ocpVersion)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFilepath, path)
+
+			data, err := storage.ReadRemoteConfig(context.Background(), path)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `"version": "`+tc.expectedVersion+`"`)
+			assert.Equal(t, tc.expectedInheritedFrom, storage.InheritedFrom(path))
+		})
+	}
+
+	// the stable channel is not changed
+	path, err := storage.GetRemoteConfigurationFilepath(context.Background(), false, "4.17.0")
+	require.NoError(t, err)
+	assert.Equal(t, channelParentFolder+"/stable/experimental.json", path)
+}
+
+// TestStorageInheritedClusterMapping checks the whole mapping is inherited
+// when the channel has no mapping file
+func TestStorageInheritedClusterMapping(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"stable/cluster_version_mapping.json": `[["4.10.0", "config.json"]]`,
+		"stable/config.json":                  `{"version":"1.0.0"}`,
+		"canary/channel.json":                 `{"parent":"stable"}`,
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, service.StableVersion), 0o700))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, service.CanaryVersion), 0o700))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	storage, err := service.NewStorage(service.StorageConfig{RemoteConfigurationsPath: dir}, true, &MockUnleashClient{})
+	require.NoError(t, err)
+
+	path, err := storage.GetRemoteConfigurationFilepath(context.Background(), true, "4.12.0")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, service.CanaryVersion, "config.json"), path)
+	_, err = storage.ReadRemoteConfig(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, service.StableVersion, storage.InheritedFrom(path))
+
+	// the mapping of the parent is not read in place of the missing one
+	assert.Empty(t, storage.InheritedFrom(filepath.Join(dir, service.CanaryVersion, "cluster_version_mapping.json")))
+}
+
+func TestEvaluationEndpointOrigin(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: channelParentFolder,
+	}, true, &MockUnleashClient{})
+	require.NoError(t, err)
+	router := mux.NewRouter()
+	service.NewHandler(service.New(service.NewRepository(storage))).Register(router)
+
+	req := httptest.NewRequest(http.MethodPost, service.APIPrefix+service.V2Prefix+service.EvaluatePath,
+		strings.NewReader(`{"cluster_version":"4.12.0"}`))
+	req.Header.Set("User-Agent", canaryUserAgent)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"version": "1.0.0",
+		"gathering_functions": [],
+		"container_logs": [],
+		"origin": {"channel":"canary","file":"config_default.json","inherited_from":"stable"}
+	}`, rr.Body.String())
+}
+
+func TestStorageInvalidChannelParent(t *testing.T) {
+	testCases := []struct {
+		name          string
+		stableChannel string
+		canaryChannel string
+		canaryMapping string
+		expectedError string
+	}{
+		{
+			name:          "inherited mapping",
+			canaryChannel: `{"parent":"stable"}`,
+		},
+		{
+			name:          "unknown parent",
+			canaryChannel: `{"parent":"beta"}`,
+			canaryMapping: `[["4.10.0", "config.json"]]`,
+			expectedError: "unknown parent channel 'beta'",
+		},
+		{
+			name:          "own parent",
+			canaryChannel: `{"parent":"canary"}`,
+			canaryMapping: `[["4.10.0", "config.json"]]`,
+			expectedError: "the channel parents make a cycle: canary -> canary",
+		},
+		{
+			name:          "cycle",
+			stableChannel: `{"parent":"canary"}`,
+			canaryChannel: `{"parent":"stable"}`,
+			expectedError: "the channel parents make a cycle: stable -> canary -> stable",
+		},
+		{
+			name:          "missing file",
+			canaryChannel: `{"parent":"stable"}`,
+			canaryMapping: `[["4.10.0", "config.json"], ["4.12.0", "missing.json"]]`,
+			expectedError: "cannot parse cluster map",
+		},
+		{
+			name:          "corrupt channel configuration",
+			canaryChannel: `{"parent":`,
+			canaryMapping: `[["4.10.0", "config.json"]]`,
+			expectedError: "channel.json' are corrupt",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{
+				"stable/cluster_version_mapping.json": `[["4.10.0", "config.json"]]`,
+				"stable/config.json":                  `{"version":"1.0.0"}`,
+				"stable/channel.json":                 tc.stableChannel,
+				"canary/channel.json":                 tc.canaryChannel,
+				"canary/cluster_version_mapping.json": tc.canaryMapping,
+			}
+			require.NoError(t, os.Mkdir(filepath.Join(dir, service.StableVersion), 0o700))
+			require.NoError(t, os.Mkdir(filepath.Join(dir, service.CanaryVersion), 0o700))
+			for name, content := range files {
+				if content != "" {
+					require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+				}
+			}
+
+			_, err := service.NewStorage(service.StorageConfig{RemoteConfigurationsPath: dir}, false, nil)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedError)
+			}
+		})
+	}
+}
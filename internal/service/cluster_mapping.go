@@ -15,6 +15,9 @@ import (
 type ClusterMapping struct {
 	rootDir string
 	mapping [][]string
+	// parent is the mapping of the parent channel, its directory provides
+	// the files missing in the root dir
+	parent *ClusterMapping
 }
 
 // NewClusterMapping creates a new ClusterMapping from a root dir and a mapping
@@ -53,7 +56,7 @@ func (cm ClusterMapping) IsValid() bool {
 		if err != nil {
 			return false
 		}
-		if _, err := os.Stat(fullFilepath); errors.Is(err, os.ErrNotExist) {
+		if !cm.exists(filepath) {
 			log.Error().Str("filepath", fullFilepath).
 				Msg("Remote configuration filepath couldn't be accessed")
 			return false
@@ -132,6 +135,71 @@ func (cm ClusterMapping) GetFilepathForVersion(ocpVersionParsed semver.Version)
 	return cm.getFullFilePath(cm.mapping[len(cm.mapping)-1][1])
 }
 
+// coverage returns the kind of the version not covered by the mapping, or
+// an empty string. The versions of the minor release of the last entry are
+// considered covered.
+func (cm ClusterMapping) coverage(version semver.Version) string {
+	if len(cm.mapping) == 0 {
+		return ""
+	}
+	first, err := semver.Make(cm.mapping[0][0])
+	if err != nil {
+		return ""
+	}
+	if version.LT(first) {
+		return UnmappedBelow
+	}
+	last, err := semver.Make(cm.mapping[len(cm.mapping)-1][0])
+	if err != nil {
+		return ""
+	}
+	if version.Major > last.Major || (version.Major == last.Major && version.Minor > last.Minor) {
+		return UnmappedLatest
+	}
+	return ""
+}
+
+// versionLabel returns the major and minor version used as a label of the
+// fleet metrics. The versions of majors missing in the mapping and the minor
+// versions too far beyond the last minor of their major in the mapping are
+// bucketed into OtherVersions, so the clients can't grow the metrics.
+func (cm ClusterMapping) versionLabel(version semver.Version) string {
+	known := false
+	for _, slice := range cm.mapping {
+		mapped, err := semver.Make(slice[0])
+		if err == nil && mapped.Major == version.Major && version.Minor <= mapped.Minor+maxMinorsAhead {
+			known = true
+		}
+	}
+	if !known {
+		return OtherVersions
+	}
+	return minorVersion(version)
+}
+
+// Filepaths returns the full filepaths of the remote configurations in the
+// cluster map. Non-local filepaths are skipped.
+func (cm ClusterMapping) Filepaths() []string {
+	var paths []string
+	for _, slice := range cm.mapping {
+		if path, err := cm.getFullFilePath(slice[1]); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// exists returns false when the file is missing in the root dir and in the
+// directories of the parent mappings
+func (cm ClusterMapping) exists(relativePath string) bool {
+	for m := &cm; m != nil; m = m.parent {
+		if _, err := os.Stat(filepath.Join(m.rootDir, relativePath)); !errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+	}
+	return false
+}
+
 func (cm ClusterMapping) getFullFilePath(relativePath string) (string, error) {
 	if !filepath.IsLocal(relativePath) {
 		log.Error().
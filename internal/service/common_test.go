@@ -17,6 +17,7 @@ limitations under the License.
 package service_test
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
@@ -136,6 +137,8 @@ var (
 	stableUserAgent = "insights-operator/4.14.27-$Format:%H$ cluster/9abc1e7a-d834-4c6d-99b1-826399958d1c"
 	canaryUserAgent = "insights-operator/4.14.27-$Format:%H$ cluster/f9fbc65a-52e6-4781-979d-1d5c6b124f9b"
 	canaryClusterID = "f9fbc65a-52e6-4781-979d-1d5c6b124f9b"
+
+	operationsToken = "0123456789abcdef0123456789abcdef"
 )
 
 type mockStorage struct {
@@ -143,20 +146,47 @@ type mockStorage struct {
 	remoteConfig                            []byte
 	remoteConfigFilepath                    string
 	getRemoteConfigurationFilepathMockError error
+	readMockError                           error
+	overlays                                []*service.Overlay
 }
 
 func (m *mockStorage) IsCanary(*http.Request) bool {
 	return true
 }
 
-func (m *mockStorage) ReadConditionalRules(bool, string) []byte {
-	return m.conditionalRules
+func (m *mockStorage) ReadConditionalRules(context.Context, bool, string) ([]byte, error) {
+	return m.conditionalRules, m.readMockError
 }
 
-func (m *mockStorage) ReadRemoteConfig(string) []byte {
-	return m.remoteConfig
+func (m *mockStorage) ReadRemoteConfig(context.Context, string) ([]byte, error) {
+	return m.remoteConfig, m.readMockError
 }
 
-func (m *mockStorage) GetRemoteConfigurationFilepath(bool, string) (string, error) {
+func (m *mockStorage) GetRemoteConfigurationFilepath(context.Context, bool, string) (string, error) {
 	return m.remoteConfigFilepath, m.getRemoteConfigurationFilepathMockError
 }
+
+func (m *mockStorage) Overlays(service.OverlayKeys) []*service.Overlay {
+	return m.overlays
+}
+
+func (m *mockStorage) AllOverlays() []*service.Overlay {
+	return m.overlays
+}
+
+func (m *mockStorage) RecordOCPVersion(bool, string) {}
+
+func (m *mockStorage) UnmappedVersions(int) []service.UnmappedVersion {
+	return nil
+}
+
+func (m *mockStorage) InheritedFrom(string) string {
+	return ""
+}
+
+func (m *mockStorage) RemoteConfigurationFilepaths() []string {
+	if m.remoteConfigFilepath == "" {
+		return nil
+	}
+	return []string{m.remoteConfigFilepath}
+}
@@ -17,55 +17,97 @@ limitations under the License.
 package service
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/api"
 	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
 )
 
-// ErrorResponse structure represents HTTP response with error message.
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
 // GatheringRulesResponse structure represents HTTP response with rules-related
-// content.
+// content. It's described by the GatheringRulesResponse schema of the OpenAPI
+// specification.
 type GatheringRulesResponse struct {
-	Version string      `json:"version"`
-	Rules   interface{} `json:"rules"`
+	Version string `json:"version"`
+	Rules   []Rule `json:"rules"`
 }
 
 // serveOpenAPI function handles requests to get OpenAPI specification file
+// embedded in the binary
 func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
 	log.Debug().Msg("Serving openapi.json file")
-	p := "./openapi.json"
 	w.Header().Set("Content-type", "application/json")
-	http.ServeFile(w, r, p)
+	http.ServeContent(w, r, "openapi.json", time.Time{}, bytes.NewReader(api.Spec))
+}
+
+// healthEndpoint reports the service is up
+func healthEndpoint(w http.ResponseWriter, _ *http.Request) {
+	err := server.SendOK(w, server.BuildOkResponse())
+	if err != nil {
+		log.Error().Err(err).Msg(merrors.ResponseDataError)
+	}
+}
+
+// readinessEndpoint returns HTTP handler function reporting the service is
+// ready to serve the remote configurations
+func readinessEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := svc.Ready(r.Context())
+		if err != nil {
+			log.Warn().Err(err).Msg("Service is not ready")
+			err = server.SendServiceUnavailable(w, err.Error())
+		} else {
+			err = server.SendOK(w, server.BuildOkResponse())
+		}
+		if err != nil {
+			log.Error().Err(err).Msg(merrors.ResponseDataError)
+		}
+	}
 }
 
 func gatheringRulesEndpoint(svc RulesProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		logger := zerolog.Ctx(r.Context())
+
 		// This is some debug logging to check if we receive the cluster ID as
 		// part of the request IO is doing
-		logHeadersEvent := log.Debug()
+		logHeadersEvent := logger.Debug()
 		logHeaders(r, []string{"User-Agent"}, logHeadersEvent)
 		logHeadersEvent.Msg("Request headers")
 
+		filter, err := ParseRuleFilter(r.URL.Query())
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+
 		rules, err := svc.Rules(r)
 		if err != nil {
 			server.HandleServerError(w, err)
 			return
 		}
 
-		log.Debug().Int("rules count", len(rules.Items)).Msg("Serving gathering rules")
+		items := filter.Apply(rules.Items)
+		logger.Debug().Int("rules count", len(items)).Msg("Serving gathering rules")
+		if filter.CountOnly {
+			renderResponse(w, &RulesCountResponse{
+				Version: rules.Version,
+				Count:   len(items),
+			}, http.StatusOK)
+			return
+		}
 		renderResponse(w, &GatheringRulesResponse{
 			Version: rules.Version,
-			Rules:   rules.Items,
+			Rules:   items,
 		}, http.StatusOK)
 	}
 }
@@ -83,20 +125,196 @@ func remoteConfigurationEndpoint(svc RulesProvider) http.HandlerFunc {
 					ErrString: "ocpVersion should be specified as part of the URL"})
 		}
 
+		filter, err := ParseRuleFilter(r.URL.Query())
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+
 		remoteConfig, err := svc.RemoteConfiguration(r, ocpVersion)
 
 		if err != nil {
 			server.HandleServerError(w, err)
 			return
 		}
+
+		// the container logs requests aren't related to the rules, so they
+		// are not filtered
+		conditionalRules := filter.Apply(remoteConfig.ConditionalRules)
+		if filter.CountOnly {
+			renderResponse(w, &RulesCountResponse{
+				Version: remoteConfig.Version,
+				Count:   len(conditionalRules),
+			}, http.StatusOK)
+			return
+		}
 		renderResponse(w, &RemoteConfiguration{
 			Version:               remoteConfig.Version,
-			ConditionalRules:      remoteConfig.ConditionalRules,
+			ConditionalRules:      conditionalRules,
 			ContainerLogsRequests: remoteConfig.ContainerLogsRequests,
 		}, http.StatusOK)
 	}
 }
 
+// evaluationEndpoint returns HTTP handler function evaluating the conditions
+// of the remote configuration for the cluster facts sent in the request body
+func evaluationEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var facts ClusterFacts
+		if err := readJSONBody(r, &facts); err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+
+		evaluator, err := NewEvaluator(facts)
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+
+		// the evaluation is a simulation, the remote configuration is not
+		// served to the cluster
+		remoteConfig, err := svc.ResolveRemoteConfiguration(r, facts.ClusterVersion)
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+
+		response := evaluator.Evaluate(remoteConfig)
+		zerolog.Ctx(r.Context()).Debug().
+			Int("gathering functions count", len(response.GatheringFunctions)).
+			Msg("Serving evaluated gathering functions")
+		renderResponse(w, response, http.StatusOK)
+	}
+}
+
+// feedbackEndpoint returns HTTP handler function receiving the outcome of
+// applying the remote configuration reported by a cluster
+func feedbackEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var feedback Feedback
+		if err := readJSONBody(r, &feedback); err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		if err := svc.ReportFeedback(r, &feedback); err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// expiredEntriesEndpoint returns HTTP handler function listing the stored
+// entries with ended activation window
+func expiredEntriesEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expired, err := svc.ExpiredEntries(r.Context())
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		renderResponse(w, &ExpiredEntriesResponse{Expired: expired}, http.StatusOK)
+	}
+}
+
+// denyListEndpoint returns HTTP handler function providing the deny-list of
+// the rules
+func denyListEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		renderResponse(w, svc.DenyList(), http.StatusOK)
+	}
+}
+
+// updateDenyListEndpoint returns HTTP handler function replacing the
+// deny-list of the rules
+func updateDenyListEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var denyList DenyList
+		if err := readJSONBody(r, &denyList); err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		if err := svc.UpdateDenyList(denyList); err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		renderResponse(w, svc.DenyList(), http.StatusOK)
+	}
+}
+
+// releaseStateEndpoint returns HTTP handler function returning the snapshots
+// served to the channels
+func releaseStateEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		renderResponse(w, svc.ReleaseState(), http.StatusOK)
+	}
+}
+
+// updateReleaseEndpoint returns HTTP handler function promoting the canary
+// snapshot, rolling back the stable one or clearing the canary one
+func updateReleaseEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, err := svc.UpdateRelease(mux.Vars(r)["operation"])
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		renderResponse(w, state, http.StatusOK)
+	}
+}
+
+// ledgerEndpoint returns HTTP handler function querying the remote
+// configurations served to the clusters
+func ledgerEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := ParseLedgerFilter(r.URL.Query())
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		records, err := svc.LedgerRecords(r.Context(), filter)
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		renderResponse(w, &LedgerResponse{Records: records}, http.StatusOK)
+	}
+}
+
+// unmappedVersionsEndpoint returns HTTP handler function reporting the most
+// requested OCP versions not covered by the cluster mappings
+func unmappedVersionsEndpoint(svc RulesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		top, err := ParseUnmappedTop(r.URL.Query())
+		if err != nil {
+			server.HandleServerError(w, err)
+			return
+		}
+		renderResponse(w, &UnmappedVersionsResponse{Versions: svc.UnmappedVersions(top)}, http.StatusOK)
+	}
+}
+
+// readJSONBody decodes the JSON request body to the given value
+func readJSONBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return &merrors.NoBodyError{}
+	}
+
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return &merrors.NoBodyError{}
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return maxBytesErr
+	}
+	if err != nil {
+		return &merrors.InvalidBodyError{Err: err}
+	}
+	return nil
+}
+
 func renderResponse(w http.ResponseWriter, resp interface{}, code int) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -0,0 +1,199 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/rs/zerolog/log"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// Condition types known by the Insights Operator
+const (
+	// AlertIsFiringCondition is met when the alert with given name is firing
+	AlertIsFiringCondition = "alert_is_firing"
+	// ClusterVersionMatchesCondition is met when the cluster version is in
+	// given semver range
+	ClusterVersionMatchesCondition = "cluster_version_matches"
+)
+
+// ClusterFacts structure represents the state of a cluster the conditions
+// are evaluated for
+type ClusterFacts struct {
+	ClusterVersion string   `json:"cluster_version"`
+	FiringAlerts   []string `json:"firing_alerts"`
+	// Namespaces existing in the cluster. The gathering functions of
+	// missing namespaces are not run. All the namespaces are considered to
+	// exist when the list is not provided.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// GatheringFunctionCall structure represents a gathering function with its
+// parameters
+type GatheringFunctionCall struct {
+	Name   string      `json:"name"`
+	Params interface{} `json:"params"`
+	// RuleID identifies the rule requesting the gathering function
+	RuleID string `json:"rule_id"`
+}
+
+// EvaluationResponse structure represents HTTP response with the gathering
+// functions that would be run for the cluster facts
+type EvaluationResponse struct {
+	Version            string                  `json:"version"`
+	GatheringFunctions []GatheringFunctionCall `json:"gathering_functions"`
+	ContainerLogs      []ContainerLogRequest   `json:"container_logs"`
+	// Origin describes the remote configuration the response is based on
+	Origin *ConfigurationOrigin `json:"origin,omitempty"`
+}
+
+// conditionEvaluator returns true when the condition is met by the facts
+type conditionEvaluator func(e *Evaluator, condition map[string]interface{}) (bool, error)
+
+var conditionEvaluators = map[string]conditionEvaluator{
+	AlertIsFiringCondition:         evaluateAlertIsFiring,
+	ClusterVersionMatchesCondition: evaluateClusterVersionMatches,
+}
+
+// Evaluator evaluates the conditions of the rules for given cluster facts
+type Evaluator struct {
+	facts   ClusterFacts
+	version semver.Version
+}
+
+// NewEvaluator function constructs new evaluator for given cluster facts.
+// The cluster version has to be a valid semver.
+func NewEvaluator(facts ClusterFacts) (*Evaluator, error) {
+	version, err := semver.Parse(facts.ClusterVersion)
+	if err != nil {
+		return nil, &merrors.ValidationError{
+			ParamName:  "cluster_version",
+			ParamValue: facts.ClusterVersion,
+			ErrString:  err.Error()}
+	}
+
+	return &Evaluator{
+		facts:   facts,
+		version: version,
+	}, nil
+}
+
+// Match returns true when all the conditions of the rule are met. Rules
+// with unknown or malformed conditions are never matched, as they would be
+// rejected by the operator.
+func (e *Evaluator) Match(rule Rule) bool {
+	for _, item := range rule.Conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			log.Warn().Interface("condition", item).Msg("Malformed condition")
+			return false
+		}
+
+		conditionType, _ := condition["type"].(string)
+		evaluate, found := conditionEvaluators[conditionType]
+		if !found {
+			log.Warn().Str("conditionType", conditionType).Msg("Unknown condition type")
+			return false
+		}
+
+		met, err := evaluate(e, condition)
+		if err != nil {
+			log.Warn().Err(err).Str("conditionType", conditionType).Msg("Malformed condition")
+			return false
+		}
+		if !met {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate returns the gathering functions of the matching rules and the
+// container logs requests for the existing namespaces
+func (e *Evaluator) Evaluate(config *RemoteConfiguration) *EvaluationResponse {
+	response := &EvaluationResponse{
+		Version:            config.Version,
+		GatheringFunctions: []GatheringFunctionCall{},
+		ContainerLogs:      []ContainerLogRequest{},
+		Origin:             config.Origin,
+	}
+
+	for _, rule := range config.ConditionalRules {
+		if !e.Match(rule) {
+			continue
+		}
+		ruleID := rule.RuleID()
+
+		functions, _ := rule.GatheringFunctions.(map[string]interface{})
+		names := make([]string, 0, len(functions))
+		for name := range functions {
+			names = append(names, name)
+		}
+		// the order of the JSON object keys is not kept
+		sort.Strings(names)
+
+		for _, name := range names {
+			params := functions[name]
+			if namespace, ok := fieldOf(params, "namespace").(string); ok && !e.namespaceExists(namespace) {
+				continue
+			}
+			response.GatheringFunctions = append(response.GatheringFunctions, GatheringFunctionCall{
+				Name:   name,
+				Params: params,
+				RuleID: ruleID,
+			})
+		}
+	}
+
+	for _, request := range config.ContainerLogsRequests {
+		if e.namespaceExists(request.Namespace) {
+			response.ContainerLogs = append(response.ContainerLogs, request)
+		}
+	}
+
+	return response
+}
+
+func (e *Evaluator) namespaceExists(namespace string) bool {
+	return e.facts.Namespaces == nil || slices.Contains(e.facts.Namespaces, namespace)
+}
+
+func evaluateAlertIsFiring(e *Evaluator, condition map[string]interface{}) (bool, error) {
+	name, ok := fieldOf(condition["alert"], "name").(string)
+	if !ok {
+		return false, fmt.Errorf("missing alert name")
+	}
+	return slices.Contains(e.facts.FiringAlerts, name), nil
+}
+
+func evaluateClusterVersionMatches(e *Evaluator, condition map[string]interface{}) (bool, error) {
+	versionRange, ok := fieldOf(condition[ClusterVersionMatchesCondition], "version").(string)
+	if !ok {
+		return false, fmt.Errorf("missing version range")
+	}
+
+	inRange, err := semver.ParseRange(versionRange)
+	if err != nil {
+		return false, err
+	}
+	return inRange(e.version), nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func alertCondition(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  service.AlertIsFiringCondition,
+		"alert": map[string]interface{}{"name": name},
+	}
+}
+
+func versionCondition(versionRange string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                                 service.ClusterVersionMatchesCondition,
+		service.ClusterVersionMatchesCondition: map[string]interface{}{"version": versionRange},
+	}
+}
+
+func TestNewEvaluatorInvalidVersion(t *testing.T) {
+	_, err := service.NewEvaluator(service.ClusterFacts{ClusterVersion: "4.16"})
+	var validationErr *merrors.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+func TestEvaluatorMatch(t *testing.T) {
+	evaluator, err := service.NewEvaluator(service.ClusterFacts{
+		ClusterVersion: "4.16.3",
+		FiringAlerts:   []string{"KubePodCrashLooping", "KubePodNotReady"},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		conditions []interface{}
+		expected   bool
+	}{
+		{"no conditions", nil, true},
+		{"alert is firing", []interface{}{alertCondition("KubePodNotReady")}, true},
+		{"alert is not firing", []interface{}{alertCondition("Watchdog")}, false},
+		{"version in range", []interface{}{versionCondition(">=4.16.0 <4.17.0")}, true},
+		{"version out of range", []interface{}{versionCondition("<4.16.0")}, false},
+		{
+			"all conditions are met",
+			[]interface{}{alertCondition("KubePodCrashLooping"), versionCondition(">=4.14.0")},
+			true,
+		},
+		{
+			"one of conditions is not met",
+			[]interface{}{alertCondition("KubePodCrashLooping"), versionCondition(">=4.17.0")},
+			false,
+		},
+		{"unknown condition type", []interface{}{map[string]interface{}{"type": "unknown"}}, false},
+		{"malformed condition", []interface{}{"condition"}, false},
+		{"missing alert name", []interface{}{map[string]interface{}{"type": service.AlertIsFiringCondition}}, false},
+		{"invalid version range", []interface{}{versionCondition("4.x.y")}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, evaluator.Match(service.Rule{Conditions: tc.conditions}))
+		})
+	}
+}
+
+func TestEvaluatorEvaluate(t *testing.T) {
+	var remoteConfig service.RemoteConfiguration
+	require.NoError(t, json.Unmarshal([]byte(configDefaultConfiguration), &remoteConfig))
+	remoteConfig.ContainerLogsRequests = []service.ContainerLogRequest{
+		{Namespace: "openshift-monitoring", PodNameRegex: "prometheus-.*", Messages: []string{"error"}},
+		{Namespace: "openshift-etcd", PodNameRegex: "etcd-.*", Messages: []string{"error"}},
+	}
+
+	testCases := []struct {
+		name                  string
+		facts                 service.ClusterFacts
+		expectedFunctions     []string
+		expectedContainerLogs int
+	}{
+		{
+			name:                  "no alert is firing",
+			facts:                 service.ClusterFacts{ClusterVersion: "4.16.3"},
+			expectedFunctions:     []string{},
+			expectedContainerLogs: 2,
+		},
+		{
+			name: "namespaces are unknown",
+			facts: service.ClusterFacts{
+				ClusterVersion: "4.16.3",
+				FiringAlerts:   []string{"KubePodNotReady", "SamplesImagestreamImportFailing"},
+			},
+			expectedFunctions:     []string{"containers_logs", "pod_definition", "image_streams_of_namespace", "logs_of_namespace"},
+			expectedContainerLogs: 2,
+		},
+		{
+			name: "functions of missing namespaces are not run",
+			facts: service.ClusterFacts{
+				ClusterVersion: "4.16.3",
+				FiringAlerts:   []string{"KubePodNotReady", "SamplesImagestreamImportFailing"},
+				Namespaces:     []string{"openshift-monitoring"},
+			},
+			expectedFunctions:     []string{"containers_logs", "pod_definition"},
+			expectedContainerLogs: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			evaluator, err := service.NewEvaluator(tc.facts)
+			require.NoError(t, err)
+
+			response := evaluator.Evaluate(&remoteConfig)
+			assert.Equal(t, "1.1.0", response.Version)
+			functions := []string{}
+			for _, function := range response.GatheringFunctions {
+				functions = append(functions, function.Name)
+			}
+			assert.Equal(t, tc.expectedFunctions, functions)
+			assert.Len(t, response.ContainerLogs, tc.expectedContainerLogs)
+		})
+	}
+}
+
+func TestEvaluationEndpoint(t *testing.T) {
+	store := mockStorage{
+		remoteConfig:         []byte(configDefaultConfiguration),
+		remoteConfigFilepath: "canary/config_default.json",
+	}
+	router := mux.NewRouter()
+	service.NewHandler(service.New(service.NewRepository(&store))).Register(router)
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "rule matches",
+			body:           `{"cluster_version":"4.16.3","firing_alerts":["KubePodCrashLooping"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"version":"1.1.0","gathering_functions":[{"name":"containers_logs","params":{"alert_name":"KubePodCrashLooping","tail_lines":20,"previous":true},"rule_id":"d8705ac67a21e8c6"}],"container_logs":[],"origin":{"channel":"canary","file":"config_default.json"}}`,
+		},
+		{
+			name:           "no body",
+			body:           "",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid body",
+			body:           `{"cluster_version":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cluster version",
+			body:           `{"cluster_version":"latest"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, service.APIPrefix+service.V2Prefix+service.EvaluatePath, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestEvaluationEndpointDryRun checks the evaluated remote configuration is
+// not counted by the metrics
+func TestEvaluationEndpointDryRun(t *testing.T) {
+	store := mockStorage{
+		remoteConfig:         []byte(configDefaultConfiguration),
+		remoteConfigFilepath: "config_default.json",
+	}
+	router := mux.NewRouter()
+	service.NewHandler(service.New(service.NewRepository(&store))).Register(router)
+
+	served := service.RemoteConfigurationsMetric.WithLabelValues("config_default.json", "1.1.0")
+	stripped := service.StrippedRulesMetric.WithLabelValues("gathering_function", "logs_of_namespace")
+	servedBefore := testutil.ToFloat64(served)
+	strippedBefore := testutil.ToFloat64(stripped)
+
+	req := httptest.NewRequest(http.MethodPost, service.APIPrefix+service.V2Prefix+service.EvaluatePath,
+		strings.NewReader(`{"cluster_version":"4.16.3","firing_alerts":["KubePodCrashLooping"]}`))
+	req.Header.Set(service.SupportedFunctionsHeader, "containers_logs,pod_definition")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, servedBefore, testutil.ToFloat64(served))
+	assert.Equal(t, strippedBefore, testutil.ToFloat64(stripped))
+
+	// the same remote configuration is counted when it's served
+	req = httptest.NewRequest(http.MethodGet, service.APIPrefix+service.V2Prefix+"/4.16.3/gathering_rules", http.NoBody)
+	req.Header.Set(service.SupportedFunctionsHeader, "containers_logs,pod_definition")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, servedBefore+1, testutil.ToFloat64(served))
+	assert.Equal(t, strippedBefore+1, testutil.ToFloat64(stripped))
+}
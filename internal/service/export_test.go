@@ -28,4 +28,19 @@ package service
 var (
 	RenderResponse = renderResponse
 	LogHeaders     = logHeaders
+
+	RemoteConfigurationsMetric   = remoteConfigurationsMetric
+	StorageErrorsMetric          = storageErrorsMetric
+	ExpiredEntriesMetric         = expiredEntriesMetric
+	StrippedRulesMetric          = strippedRulesMetric
+	DenyListMetric               = denyListMetric
+	ReleaseSnapshotMetric        = releaseSnapshotMetric
+	FeedbackReportsMetric        = feedbackReportsMetric
+	FeedbackGathererErrorsMetric = feedbackGathererErrorsMetric
+	FeedbackErrorRateMetric      = feedbackErrorRateMetric
+	GuardrailTripsMetric         = guardrailTripsMetric
+	LedgerRecordsMetric          = ledgerRecordsMetric
+	LedgerAvailableMetric        = ledgerAvailableMetric
+	FleetVersionsMetric          = fleetVersionsMetric
+	UnmappedVersionsMetric       = unmappedVersionsMetric
 )
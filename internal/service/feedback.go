@@ -0,0 +1,229 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// MaxFeedbackGatherers is the highest number of gatherers of a feedback
+const MaxFeedbackGatherers = 256
+
+// unknownLabel replaces the versions and the gatherer names not known to
+// the service in the labels of the feedback metrics
+const unknownLabel = "unknown"
+
+// conditionalGathererPrefix is the prefix of the names of the gatherers run
+// by the conditional gathering functions
+const conditionalGathererPrefix = "conditional/"
+
+// outcomes of the feedbacks
+const (
+	feedbackSuccess = "success"
+	feedbackFailure = "failure"
+)
+
+// feedbackLabelRegex matches the versions and the gatherer names, they're
+// used as labels of the metrics
+var feedbackLabelRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+/-]{0,127}$`)
+
+// GathererFeedback structure represents the outcome of a gatherer
+type GathererFeedback struct {
+	Name       string   `json:"name"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// Feedback structure represents the outcome of applying a remote
+// configuration reported by a cluster
+type Feedback struct {
+	// Version is the version of the applied remote configuration
+	Version   string             `json:"version"`
+	Gatherers []GathererFeedback `json:"gatherers"`
+}
+
+// Validate checks the feedback can be aggregated
+func (f *Feedback) Validate() error {
+	invalid := func(name string, value interface{}, reason string) error {
+		return &merrors.ValidationError{ParamName: name, ParamValue: value, ErrString: reason}
+	}
+
+	if !feedbackLabelRegex.MatchString(f.Version) {
+		return invalid("version", f.Version, "invalid version")
+	}
+	if len(f.Gatherers) > MaxFeedbackGatherers {
+		return invalid("gatherers", len(f.Gatherers), fmt.Sprintf("at most %d gatherers are allowed", MaxFeedbackGatherers))
+	}
+	for i, gatherer := range f.Gatherers {
+		if !feedbackLabelRegex.MatchString(gatherer.Name) {
+			return invalid(fmt.Sprintf("gatherers[%d].name", i), gatherer.Name, "invalid gatherer name")
+		}
+		if gatherer.DurationMS < 0 {
+			return invalid(fmt.Sprintf("gatherers[%d].duration_ms", i), gatherer.DurationMS, "negative duration")
+		}
+	}
+	return nil
+}
+
+// Failed returns true when any gatherer failed
+func (f *Feedback) Failed() bool {
+	for _, gatherer := range f.Gatherers {
+		if len(gatherer.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gathererLabel returns the gatherer name used as a label of the metrics.
+// The conditional gatherers are known by their gathering functions, the
+// other names are replaced by unknownLabel.
+func gathererLabel(name string, gatheringFunctions map[string]bool) string {
+	if gatheringFunctions[name] || gatheringFunctions[strings.TrimPrefix(name, conditionalGathererPrefix)] {
+		return name
+	}
+	return unknownLabel
+}
+
+// recordFeedbackMetrics aggregates the feedback by the channel and version
+func recordFeedbackMetrics(feedback *Feedback, channel, version string, gatheringFunctions map[string]bool) {
+	outcome := feedbackSuccess
+	if feedback.Failed() {
+		outcome = feedbackFailure
+	}
+	feedbackReportsMetric.WithLabelValues(channel, version, outcome).Inc()
+
+	for _, gatherer := range feedback.Gatherers {
+		name := gathererLabel(gatherer.Name, gatheringFunctions)
+		if len(gatherer.Errors) > 0 {
+			feedbackGathererErrorsMetric.WithLabelValues(channel, version, name).Add(float64(len(gatherer.Errors)))
+		}
+		duration := time.Duration(gatherer.DurationMS) * time.Millisecond
+		feedbackGathererDurationMetric.WithLabelValues(channel, name).Observe(duration.Seconds())
+	}
+}
+
+// GuardrailConfig structure contains configuration of the guardrail
+// clearing the canary snapshot
+type GuardrailConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled"`
+	// MaxErrorRateDelta is the highest allowed difference between the error
+	// rates of the canary and stable feedbacks, like 0.1
+	MaxErrorRateDelta float64 `mapstructure:"max_error_rate_delta" toml:"max_error_rate_delta"`
+	// MinReports is the number of feedbacks of each channel needed to
+	// compare the error rates, so the canary is never compared against an
+	// empty stable baseline
+	MinReports int `mapstructure:"min_reports" toml:"min_reports"`
+	// Window is the period of the compared feedbacks, the feedbacks are
+	// never forgotten when it's zero
+	Window time.Duration `mapstructure:"window" toml:"window"`
+}
+
+// Validate checks the enabled guardrail compares enough feedbacks and allows
+// a difference of the error rates
+func (cfg GuardrailConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MinReports < 1 {
+		return fmt.Errorf("the guardrail is enabled, but min_reports is not positive")
+	}
+	if cfg.MaxErrorRateDelta <= 0 || cfg.MaxErrorRateDelta >= 1 {
+		return fmt.Errorf("max_error_rate_delta of the guardrail must be between 0 and 1")
+	}
+	if cfg.Window < 0 {
+		return fmt.Errorf("window of the guardrail can't be negative")
+	}
+	return nil
+}
+
+// feedbackCounts holds the numbers of the feedbacks of a channel
+type feedbackCounts struct {
+	reports  int
+	failures int
+}
+
+func (c feedbackCounts) errorRate() float64 {
+	if c.reports == 0 {
+		return 0
+	}
+	return float64(c.failures) / float64(c.reports)
+}
+
+// Guardrail compares the error rates of the canary and stable feedbacks
+// of the current window
+type Guardrail struct {
+	config      GuardrailConfig
+	mutex       sync.Mutex
+	windowStart time.Time
+	counts      map[string]feedbackCounts
+}
+
+// NewGuardrail constructs new guardrail
+func NewGuardrail(config GuardrailConfig) *Guardrail {
+	return &Guardrail{
+		config: config,
+		counts: map[string]feedbackCounts{},
+	}
+}
+
+// Record counts the feedback of the channel. It returns true when the
+// error rate of the canary feedbacks is too high, the counts are reset
+// then.
+func (g *Guardrail) Record(now time.Time, channel string, failed bool) bool {
+	if !g.config.Enabled {
+		return false
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.config.Window > 0 && now.Sub(g.windowStart) >= g.config.Window {
+		g.reset(now)
+	}
+	counts := g.counts[channel]
+	counts.reports++
+	if failed {
+		counts.failures++
+	}
+	g.counts[channel] = counts
+
+	canary, stable := g.counts[CanaryVersion], g.counts[StableVersion]
+	feedbackErrorRateMetric.WithLabelValues(channel).Set(counts.errorRate())
+	if canary.reports < g.config.MinReports || stable.reports < g.config.MinReports {
+		return false
+	}
+	if canary.errorRate()-stable.errorRate() <= g.config.MaxErrorRateDelta {
+		return false
+	}
+
+	g.reset(now)
+	return true
+}
+
+// reset forgets the feedbacks and starts a new window, the mutex must be
+// held
+func (g *Guardrail) reset(now time.Time) {
+	g.windowStart = now
+	g.counts = map[string]feedbackCounts{}
+}
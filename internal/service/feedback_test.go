@@ -0,0 +1,217 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestFeedbackValidate(t *testing.T) {
+	tooMany := make([]service.GathererFeedback, service.MaxFeedbackGatherers+1)
+	for i := range tooMany {
+		tooMany[i].Name = fmt.Sprintf("gatherer_%d", i)
+	}
+
+	testCases := []struct {
+		name          string
+		feedback      service.Feedback
+		expectedParam string
+	}{
+		{
+			name: "valid",
+			feedback: service.Feedback{Version: "1.2.0-canary", Gatherers: []service.GathererFeedback{
+				{Name: "conditional/containers_logs", Errors: []string{"timeout"}, DurationMS: 100},
+			}},
+		},
+		{"missing version", service.Feedback{}, "version"},
+		{"invalid version", service.Feedback{Version: "1.0.0 beta"}, "version"},
+		{"too many gatherers", service.Feedback{Version: "1.0.0", Gatherers: tooMany}, "gatherers"},
+		{
+			name:          "invalid gatherer name",
+			feedback:      service.Feedback{Version: "1.0.0", Gatherers: []service.GathererFeedback{{Name: strings.Repeat("a", 200)}}},
+			expectedParam: "gatherers[0].name",
+		},
+		{
+			name:          "negative duration",
+			feedback:      service.Feedback{Version: "1.0.0", Gatherers: []service.GathererFeedback{{Name: "workloads", DurationMS: -1}}},
+			expectedParam: "gatherers[0].duration_ms",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.feedback.Validate()
+			if tc.expectedParam == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *merrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.expectedParam, validationErr.ParamName)
+		})
+	}
+}
+
+func TestGuardrailConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        service.GuardrailConfig
+		expectedError bool
+	}{
+		{"disabled", service.GuardrailConfig{}, false},
+		{"valid", service.GuardrailConfig{Enabled: true, MaxErrorRateDelta: 0.1, MinReports: 50, Window: time.Hour}, false},
+		{"no minimum of reports", service.GuardrailConfig{Enabled: true, MaxErrorRateDelta: 0.1}, true},
+		{"no allowed delta", service.GuardrailConfig{Enabled: true, MinReports: 50}, true},
+		{"too high delta", service.GuardrailConfig{Enabled: true, MaxErrorRateDelta: 1, MinReports: 50}, true},
+		{"negative window", service.GuardrailConfig{Enabled: true, MaxErrorRateDelta: 0.1, MinReports: 50, Window: -time.Hour}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGuardrailRecord(t *testing.T) {
+	guardrail := service.NewGuardrail(service.GuardrailConfig{
+		Enabled:           true,
+		MaxErrorRateDelta: 0.2,
+		MinReports:        3,
+		Window:            time.Hour,
+	})
+
+	// the canary error rate is compared only after the minimum of reports
+	assert.False(t, guardrail.Record(activationNow, service.StableVersion, false))
+	assert.False(t, guardrail.Record(activationNow, service.CanaryVersion, true))
+	assert.False(t, guardrail.Record(activationNow, service.CanaryVersion, true))
+	assert.InDelta(t, 1.0, testutil.ToFloat64(service.FeedbackErrorRateMetric.WithLabelValues(service.CanaryVersion)), 0.001)
+
+	// the reports of the previous window are forgotten
+	later := activationNow.Add(time.Hour)
+	assert.False(t, guardrail.Record(later, service.CanaryVersion, true))
+	assert.False(t, guardrail.Record(later, service.CanaryVersion, false))
+	assert.False(t, guardrail.Record(later, service.StableVersion, true))
+	// canary 2/3 failed, stable 1/1 failed
+	assert.False(t, guardrail.Record(later, service.CanaryVersion, true))
+	// canary 2/3 failed, stable 1/2 failed
+	assert.False(t, guardrail.Record(later, service.StableVersion, false))
+	// canary 2/3 failed, stable 1/3 failed
+	assert.True(t, guardrail.Record(later, service.StableVersion, false))
+
+	// the counts are reset after the guardrail trips
+	assert.False(t, guardrail.Record(later, service.CanaryVersion, true))
+
+	// the canary is not compared without the minimum of stable reports
+	noBaseline := service.NewGuardrail(service.GuardrailConfig{
+		Enabled:           true,
+		MaxErrorRateDelta: 0.2,
+		MinReports:        3,
+	})
+	for range 10 {
+		assert.False(t, noBaseline.Record(activationNow, service.CanaryVersion, true))
+	}
+	assert.False(t, noBaseline.Record(activationNow, service.StableVersion, false))
+	assert.False(t, noBaseline.Record(activationNow, service.StableVersion, false))
+	assert.True(t, noBaseline.Record(activationNow, service.StableVersion, false))
+
+	disabled := service.NewGuardrail(service.GuardrailConfig{MinReports: 1})
+	assert.False(t, disabled.Record(activationNow, service.CanaryVersion, true))
+}
+
+// TestFeedbackEndpoint checks the feedbacks are counted in the metrics by the
+// channel serving the cluster and the canary snapshot is cleared when its
+// error rate is too high
+func TestFeedbackEndpoint(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: channelParentFolder,
+	}, true, &MockUnleashClient{})
+	require.NoError(t, err)
+	releases, err := service.NewReleases("", storage.SnapshotIDs())
+	require.NoError(t, err)
+	repo := service.NewRepository(storage)
+	repo.SetReleases(releases)
+	repo.SetClock(func() time.Time { return activationNow })
+	repo.SetGuardrail(service.NewGuardrail(service.GuardrailConfig{
+		Enabled:           true,
+		MaxErrorRateDelta: 0.5,
+		MinReports:        2,
+	}))
+	router := mux.NewRouter()
+	service.NewHandler(service.New(repo)).Register(router)
+
+	report := func(t *testing.T, userAgent, body string) int {
+		req := httptest.NewRequest(http.MethodPost, service.APIPrefix+service.V2Prefix+service.FeedbackPath, strings.NewReader(body))
+		req.Header.Set("User-Agent", userAgent)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	failed := `{"version":"1.2.0-canary","gatherers":[{"name":"conditional/containers_logs","errors":["timeout"],"duration_ms":250}]}`
+
+	reports := service.FeedbackReportsMetric.WithLabelValues(service.CanaryVersion, "1.2.0-canary", "failure")
+	gathererErrors := service.FeedbackGathererErrorsMetric.WithLabelValues(service.CanaryVersion, "1.2.0-canary", "conditional/containers_logs")
+	unknown := service.FeedbackReportsMetric.WithLabelValues(service.StableVersion, "unknown", "success")
+	unknownGatherer := service.FeedbackGathererErrorsMetric.WithLabelValues(service.StableVersion, "1.1.0", "unknown")
+	initialReports, initialErrors, initialUnknown := testutil.ToFloat64(reports), testutil.ToFloat64(gathererErrors), testutil.ToFloat64(unknown)
+	initialUnknownGatherer := testutil.ToFloat64(unknownGatherer)
+	initialTrips := testutil.ToFloat64(service.GuardrailTripsMetric)
+
+	assert.Equal(t, http.StatusNoContent, report(t, "insights-operator/v1.0.0", `{"version":"9.9.9","gatherers":[]}`))
+	// the stable baseline needed for comparing the error rates
+	assert.Equal(t, http.StatusNoContent, report(t, "insights-operator/v1.0.0", `{"version":"1.1.0","gatherers":[]}`))
+	assert.Equal(t, http.StatusBadRequest, report(t, canaryUserAgent, `{"version":""}`))
+	assert.Equal(t, http.StatusBadRequest, report(t, canaryUserAgent, `{"version":`))
+
+	assert.Equal(t, http.StatusNoContent, report(t, canaryUserAgent, failed))
+	assert.Equal(t, service.CanaryVersion, releases.State().Canary)
+	assert.Equal(t, http.StatusNoContent, report(t, canaryUserAgent, failed))
+	assert.Empty(t, releases.State().Canary)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(reports)-initialReports)
+	assert.Equal(t, 2.0, testutil.ToFloat64(gathererErrors)-initialErrors)
+	assert.Equal(t, 1.0, testutil.ToFloat64(unknown)-initialUnknown)
+	assert.Equal(t, 1.0, testutil.ToFloat64(service.GuardrailTripsMetric)-initialTrips)
+
+	// the canary clusters are served the stable snapshot, so their reports
+	// don't trip the guardrail again, whatever channel they claim
+	assert.Equal(t, http.StatusNoContent, report(t, canaryUserAgent, `{"version":"1.1.0","gatherers":[{"name":"workloads","errors":["timeout"]}]}`))
+	assert.Equal(t, http.StatusNoContent, report(t, canaryUserAgent, `{"version":"1.1.0","channel":"canary","gatherers":[{"name":"workloads","errors":["timeout"]}]}`))
+	assert.Equal(t, 1.0, testutil.ToFloat64(service.GuardrailTripsMetric)-initialTrips)
+
+	// the gatherers without a gathering function of the loaded remote
+	// configurations are counted as unknown
+	assert.Equal(t, 2.0, testutil.ToFloat64(unknownGatherer)-initialUnknownGatherer)
+}
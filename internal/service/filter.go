@@ -0,0 +1,145 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// Query parameters of the rules endpoints
+const (
+	ConditionTypeParam     = "condition_type"
+	AlertNameParam         = "alert_name"
+	GatheringFunctionParam = "gathering_function"
+	CountParam             = "count"
+)
+
+// RuleFilter selects the rules by their conditions and gathering functions.
+// A rule is selected when it matches all the given criteria, a criterion is
+// matched by any of its values.
+type RuleFilter struct {
+	ConditionTypes     []string
+	AlertNames         []string
+	GatheringFunctions []string
+	// CountOnly requests just the number of the selected rules
+	CountOnly bool
+}
+
+// RulesCountResponse structure represents HTTP response with the number of
+// the selected rules
+type RulesCountResponse struct {
+	Version string `json:"version"`
+	Count   int    `json:"count"`
+}
+
+// ParseRuleFilter reads the filter from the query parameters. The values of
+// a parameter can be repeated or separated by commas.
+func ParseRuleFilter(query url.Values) (RuleFilter, error) {
+	filter := RuleFilter{
+		ConditionTypes:     queryValues(query, ConditionTypeParam),
+		AlertNames:         queryValues(query, AlertNameParam),
+		GatheringFunctions: queryValues(query, GatheringFunctionParam),
+	}
+
+	if count := query.Get(CountParam); count != "" {
+		countOnly, err := strconv.ParseBool(count)
+		if err != nil {
+			return RuleFilter{}, &merrors.ValidationError{
+				ParamName:  CountParam,
+				ParamValue: count,
+				ErrString:  "expected a boolean value"}
+		}
+		filter.CountOnly = countOnly
+	}
+
+	return filter, nil
+}
+
+func queryValues(query url.Values, param string) []string {
+	var values []string
+	for _, value := range query[param] {
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// IsEmpty returns true when the filter selects all the rules
+func (f RuleFilter) IsEmpty() bool {
+	return len(f.ConditionTypes) == 0 && len(f.AlertNames) == 0 && len(f.GatheringFunctions) == 0
+}
+
+// Apply returns the rules selected by the filter
+func (f RuleFilter) Apply(rules []Rule) []Rule {
+	if f.IsEmpty() {
+		return rules
+	}
+
+	selected := []Rule{}
+	for _, rule := range rules {
+		if f.Match(rule) {
+			selected = append(selected, rule)
+		}
+	}
+	return selected
+}
+
+// Match returns true when the rule meets all the criteria of the filter
+func (f RuleFilter) Match(rule Rule) bool {
+	if len(f.ConditionTypes) > 0 && !slices.ContainsFunc(rule.Conditions, func(condition interface{}) bool {
+		conditionType, _ := fieldOf(condition, "type").(string)
+		return slices.Contains(f.ConditionTypes, conditionType)
+	}) {
+		return false
+	}
+
+	if len(f.AlertNames) > 0 && !slices.ContainsFunc(rule.Conditions, func(condition interface{}) bool {
+		alertName, _ := fieldOf(fieldOf(condition, "alert"), "name").(string)
+		return slices.Contains(f.AlertNames, alertName)
+	}) {
+		return false
+	}
+
+	if len(f.GatheringFunctions) > 0 {
+		functions, _ := rule.GatheringFunctions.(map[string]interface{})
+		if !slices.ContainsFunc(f.GatheringFunctions, func(name string) bool {
+			_, found := functions[name]
+			return found
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fieldOf returns the field of decoded JSON object or nil
+func fieldOf(object interface{}, name string) interface{} {
+	fields, ok := object.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return fields[name]
+}
@@ -0,0 +1,209 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+// alertNames returns the alert names of the first conditions of the rules
+func alertNames(rules []service.Rule) []string {
+	names := []string{}
+	for _, rule := range rules {
+		condition := rule.Conditions[0].(map[string]interface{})
+		names = append(names, condition["alert"].(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestParseRuleFilter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		expectedFilter service.RuleFilter
+		expectedError  bool
+	}{
+		{
+			name:           "no parameters",
+			query:          "",
+			expectedFilter: service.RuleFilter{},
+		},
+		{
+			name:  "repeated and comma separated values",
+			query: "alert_name=A,B&alert_name=C&condition_type=alert_is_firing&gathering_function=containers_logs,",
+			expectedFilter: service.RuleFilter{
+				ConditionTypes:     []string{"alert_is_firing"},
+				AlertNames:         []string{"A", "B", "C"},
+				GatheringFunctions: []string{"containers_logs"},
+			},
+		},
+		{
+			name:           "count",
+			query:          "count=true",
+			expectedFilter: service.RuleFilter{CountOnly: true},
+		},
+		{
+			name:          "invalid count",
+			query:         "count=maybe",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			filter, err := service.ParseRuleFilter(query)
+			if tc.expectedError {
+				var validationErr *merrors.ValidationError
+				assert.ErrorAs(t, err, &validationErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedFilter, filter)
+		})
+	}
+}
+
+func TestRuleFilterApply(t *testing.T) {
+	var remoteConfig service.RemoteConfiguration
+	require.NoError(t, json.Unmarshal([]byte(configDefaultConfiguration), &remoteConfig))
+
+	testCases := []struct {
+		name          string
+		filter        service.RuleFilter
+		expectedRules []string
+	}{
+		{
+			name:   "empty filter",
+			filter: service.RuleFilter{},
+			expectedRules: []string{
+				"AlertmanagerFailedReload", "AlertmanagerFailedToSendAlerts", "APIRemovedInNextEUSReleaseInUse",
+				"KubePodCrashLooping", "KubePodNotReady", "PrometheusOperatorSyncFailed",
+				"PrometheusTargetSyncFailure", "SamplesImagestreamImportFailing", "ThanosRuleQueueIsDroppingAlerts",
+			},
+		},
+		{
+			name:          "alert names",
+			filter:        service.RuleFilter{AlertNames: []string{"KubePodNotReady", "KubePodCrashLooping"}},
+			expectedRules: []string{"KubePodCrashLooping", "KubePodNotReady"},
+		},
+		{
+			name:          "gathering function",
+			filter:        service.RuleFilter{GatheringFunctions: []string{"pod_definition", "logs_of_namespace"}},
+			expectedRules: []string{"KubePodNotReady", "SamplesImagestreamImportFailing"},
+		},
+		{
+			name: "all criteria must match",
+			filter: service.RuleFilter{
+				ConditionTypes:     []string{"alert_is_firing"},
+				AlertNames:         []string{"KubePodNotReady", "SamplesImagestreamImportFailing"},
+				GatheringFunctions: []string{"containers_logs"},
+			},
+			expectedRules: []string{"KubePodNotReady"},
+		},
+		{
+			name:          "unknown condition type",
+			filter:        service.RuleFilter{ConditionTypes: []string{"cluster_version_matches"}},
+			expectedRules: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedRules, alertNames(tc.filter.Apply(remoteConfig.ConditionalRules)))
+		})
+	}
+}
+
+// TestRulesFilterEndpoints checks the filtering and counting of the rules by
+// the endpoints
+func TestRulesFilterEndpoints(t *testing.T) {
+	var remoteConfig service.RemoteConfiguration
+	require.NoError(t, json.Unmarshal([]byte(configDefaultConfiguration), &remoteConfig))
+	rules, err := json.Marshal(service.Rules{Version: "1.1.0", Items: remoteConfig.ConditionalRules})
+	require.NoError(t, err)
+
+	store := mockStorage{
+		conditionalRules: rules,
+		remoteConfig:     []byte(configDefaultConfiguration),
+	}
+	router := mux.NewRouter()
+	service.NewHandler(service.New(service.NewRepository(&store))).Register(router)
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "v1 rules filtered by alert",
+			url:            "/v1/gathering_rules?alert_name=APIRemovedInNextEUSReleaseInUse",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"version":"1.1.0","rules":[{"conditions":[{"alert":{"name":"APIRemovedInNextEUSReleaseInUse"},"type":"alert_is_firing"}],"gathering_functions":{"api_request_counts_of_resource_from_alert":{"alert_name":"APIRemovedInNextEUSReleaseInUse"}}}]}`,
+		},
+		{
+			name:           "v1 count of rules",
+			url:            "/v1/gathering_rules?gathering_function=containers_logs&count=true",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"version":"1.1.0","count":7}`,
+		},
+		{
+			name:           "v2 no rule matches",
+			url:            "/v2/4.16.0/gathering_rules?alert_name=Unknown",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"conditional_gathering_rules":[],"container_logs":[],"version":"1.1.0"}`,
+		},
+		{
+			name:           "v2 count of rules",
+			url:            "/v2/4.16.0/gathering_rules?alert_name=KubePodNotReady,KubePodCrashLooping&count=1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"version":"1.1.0","count":2}`,
+		},
+		{
+			name:           "v2 invalid count",
+			url:            "/v2/4.16.0/gathering_rules?count=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, service.APIPrefix+tc.url, http.NoBody)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
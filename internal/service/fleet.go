@@ -0,0 +1,162 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// Kinds of the OCP versions not covered by the cluster mapping
+const (
+	// UnmappedBelow is a version lower than the first entry of the mapping
+	UnmappedBelow = "below_mapping"
+	// UnmappedLatest is a version of a minor release newer than the last
+	// entry of the mapping, it's served the latest remote configuration
+	UnmappedLatest = "latest_fallthrough"
+)
+
+// OtherVersions is the label of the OCP versions of the fleet metrics too far
+// from the versions of the cluster mapping
+const OtherVersions = "other"
+
+// TopParam is the query parameter of the number of the reported unmapped
+// versions
+const TopParam = "top"
+
+const (
+	defaultUnmappedTop = 10
+	maxUnmappedTop     = 100
+	// maxUnmappedVersions limits the number of the versions kept for the
+	// report, the versions over the limit are only counted by the metric
+	maxUnmappedVersions = 1000
+	// maxMinorsAhead is the number of the minor versions beyond the last one
+	// of the cluster mapping counted by their own label
+	maxMinorsAhead = 10
+)
+
+// UnmappedVersion structure represents the requests for an OCP version not
+// covered by the cluster mapping of the channel
+type UnmappedVersion struct {
+	// OCPVersion is the major and minor version, like 4.18
+	OCPVersion string    `json:"ocp_version"`
+	Channel    string    `json:"channel"`
+	Kind       string    `json:"kind"`
+	Count      int       `json:"count"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// UnmappedVersionsResponse structure represents HTTP response with the most
+// requested unmapped versions
+type UnmappedVersionsResponse struct {
+	Versions []UnmappedVersion `json:"versions"`
+}
+
+// ParseUnmappedTop reads the number of the reported versions from the query
+// parameters
+func ParseUnmappedTop(query url.Values) (int, error) {
+	raw := query.Get(TopParam)
+	if raw == "" {
+		return defaultUnmappedTop, nil
+	}
+	top, err := strconv.Atoi(raw)
+	if err != nil || top < 1 || top > maxUnmappedTop {
+		return 0, &merrors.ValidationError{
+			ParamName:  TopParam,
+			ParamValue: raw,
+			ErrString:  fmt.Sprintf("expected a number from 1 to %d", maxUnmappedTop)}
+	}
+	return top, nil
+}
+
+// minorVersion returns the major and minor version, like 4.18
+func minorVersion(version semver.Version) string {
+	return fmt.Sprintf("%d.%d", version.Major, version.Minor)
+}
+
+// unmappedKey identifies the unmapped version of the report
+type unmappedKey struct {
+	version string
+	channel string
+	kind    string
+}
+
+// fleetVersions counts the requested OCP versions not covered by the
+// cluster mappings
+type fleetVersions struct {
+	mutex    sync.Mutex
+	unmapped map[unmappedKey]*UnmappedVersion
+}
+
+// record counts the request for the version label of the channel by the
+// metrics and, if it's not covered by the mapping, for the report
+func (f *fleetVersions) record(channel, minor, kind string, now time.Time) {
+	fleetVersionsMetric.WithLabelValues(minor, channel).Inc()
+	if kind == "" {
+		return
+	}
+	unmappedVersionsMetric.WithLabelValues(minor, channel, kind).Inc()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.unmapped == nil {
+		f.unmapped = map[unmappedKey]*UnmappedVersion{}
+	}
+	key := unmappedKey{version: minor, channel: channel, kind: kind}
+	entry, found := f.unmapped[key]
+	if !found {
+		if len(f.unmapped) >= maxUnmappedVersions {
+			return
+		}
+		entry = &UnmappedVersion{OCPVersion: minor, Channel: channel, Kind: kind}
+		f.unmapped[key] = entry
+	}
+	entry.Count++
+	entry.LastSeen = now
+}
+
+// top returns the most requested unmapped versions
+func (f *fleetVersions) top(n int) []UnmappedVersion {
+	f.mutex.Lock()
+	versions := make([]UnmappedVersion, 0, len(f.unmapped))
+	for _, entry := range f.unmapped {
+		versions = append(versions, *entry)
+	}
+	f.mutex.Unlock()
+
+	slices.SortFunc(versions, func(a, b UnmappedVersion) int {
+		return cmp.Or(
+			cmp.Compare(b.Count, a.Count),
+			cmp.Compare(a.OCPVersion, b.OCPVersion),
+			cmp.Compare(a.Channel, b.Channel),
+			cmp.Compare(a.Kind, b.Kind),
+		)
+	})
+	if len(versions) > n {
+		versions = versions[:n]
+	}
+	return versions
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+// TestStorageUnmappedVersions checks the requested versions are counted by
+// their minor versions and the ones not covered by the mappings are reported
+func TestStorageUnmappedVersions(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: channelParentFolder,
+	}, false, nil)
+	require.NoError(t, err)
+
+	fleet := service.FleetVersionsMetric.WithLabelValues("4.9", service.StableVersion)
+	other := service.FleetVersionsMetric.WithLabelValues(service.OtherVersions, service.CanaryVersion)
+	below := service.UnmappedVersionsMetric.WithLabelValues("4.9", service.StableVersion, service.UnmappedBelow)
+	latest := service.UnmappedVersionsMetric.WithLabelValues("4.17", service.CanaryVersion, service.UnmappedLatest)
+	initialFleet, initialOther := testutil.ToFloat64(fleet), testutil.ToFloat64(other)
+	initialBelow, initialLatest := testutil.ToFloat64(below), testutil.ToFloat64(latest)
+
+	testCases := []struct {
+		ocpVersion string
+		isCanary   bool
+	}{
+		{"4.9.5", false},
+		{"4.9.12", false},
+		{"4.14.7", false},
+		{"4.16.1", false},
+		{"4.16.1", true},
+		{"4.17.0", true},
+		{"4.27.0", true},
+		{"5.1.0", true},
+		{"latest", true},
+	}
+	for _, tc := range testCases {
+		storage.RecordOCPVersion(tc.isCanary, tc.ocpVersion)
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(fleet)-initialFleet)
+	assert.Equal(t, 2.0, testutil.ToFloat64(other)-initialOther)
+	assert.Equal(t, 2.0, testutil.ToFloat64(below)-initialBelow)
+	assert.Equal(t, 1.0, testutil.ToFloat64(latest)-initialLatest)
+
+	versions := storage.UnmappedVersions(10)
+	require.Len(t, versions, 4)
+	for i, expected := range []service.UnmappedVersion{
+		{OCPVersion: "4.9", Channel: service.StableVersion, Kind: service.UnmappedBelow, Count: 2},
+		{OCPVersion: service.OtherVersions, Channel: service.CanaryVersion, Kind: service.UnmappedLatest, Count: 2},
+		{OCPVersion: "4.16", Channel: service.StableVersion, Kind: service.UnmappedLatest, Count: 1},
+		{OCPVersion: "4.17", Channel: service.CanaryVersion, Kind: service.UnmappedLatest, Count: 1},
+	} {
+		assert.False(t, versions[i].LastSeen.IsZero())
+		versions[i].LastSeen = expected.LastSeen
+		assert.Equal(t, expected, versions[i])
+	}
+	assert.Len(t, storage.UnmappedVersions(1), 1)
+}
+
+func TestUnmappedVersionsEndpoint(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: channelParentFolder,
+	}, false, nil)
+	require.NoError(t, err)
+	router := mux.NewRouter()
+	handler := service.NewHandler(service.New(service.NewRepository(storage)))
+	handler.Register(router)
+	handler.RegisterAdmin(router)
+
+	for _, ocpVersion := range []string{"4.8.0", "4.9.0", "4.9.1"} {
+		req := httptest.NewRequest(http.MethodGet, service.APIPrefix+service.V2Prefix+"/"+ocpVersion+"/gathering_rules", http.NoBody)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	}
+
+	// the evaluations of the conditions are not counted
+	req := httptest.NewRequest(http.MethodPost, service.APIPrefix+service.V2Prefix+service.EvaluatePath,
+		strings.NewReader(`{"cluster_version":"4.9.2"}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, service.UnmappedVersionsPath+"?top=1", http.NoBody)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response service.UnmappedVersionsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Versions, 1)
+	assert.Equal(t, "4.9", response.Versions[0].OCPVersion)
+	assert.Equal(t, 2, response.Versions[0].Count)
+
+	for _, top := range []string{"0", "1000", "all"} {
+		req := httptest.NewRequest(http.MethodGet, service.UnmappedVersionsPath+"?top="+top, http.NoBody)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, top)
+	}
+}
@@ -18,15 +18,47 @@ package service
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/pprof"
 
 	"github.com/gorilla/mux"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // APIPrefix is the prefix used in the console-dot environment
 const APIPrefix = "/api/gathering"
 
+const (
+	// MetricsPath is the path of Prometheus metrics endpoint
+	MetricsPath = "/metrics"
+	// HealthPath is the path of liveness probe endpoint
+	HealthPath = "/health"
+	// ReadinessPath is the path of readiness probe endpoint
+	ReadinessPath = "/ready"
+	// ExpiredPath is the path of the admin endpoint listing the rules and
+	// container logs requests with ended activation window
+	ExpiredPath = "/expired"
+	// DenyListPath is the path of the admin endpoint reading and replacing
+	// the deny-list of the rules
+	DenyListPath = "/deny_list"
+	// ReleasesPath is the path of the admin endpoints reading and changing
+	// the snapshots served to the channels
+	ReleasesPath = "/releases"
+	// LedgerPath is the path of the admin endpoint querying the remote
+	// configurations served to the clusters
+	LedgerPath = "/ledger"
+	// UnmappedVersionsPath is the path of the admin endpoint reporting the
+	// most requested OCP versions not covered by the cluster mappings
+	UnmappedVersionsPath = "/unmapped_versions"
+	// DebugPrefix is the prefix of the profiling endpoints
+	DebugPrefix = "/debug/pprof"
+	// EvaluatePath is the path of the conditions evaluation endpoint of the
+	// API v2
+	EvaluatePath = "/gathering_rules/evaluate"
+	// FeedbackPath is the path of the endpoint of the API v2 receiving the
+	// outcomes of applying the remote configurations
+	FeedbackPath = "/feedback"
+)
+
 const (
 	// V1Prefix is the version prefix used in the console-dot environment
 	V1Prefix = "/v1"
@@ -49,11 +81,6 @@ func NewHandler(svc RulesProvider) *Handler {
 // Register function registers new handler for given endpoint URL.
 func (s *Handler) Register(r *mux.Router) {
 	r.HandleFunc(APIPrefix+"/openapi.json", serveOpenAPI).Methods("GET")
-
-	registry := prometheus.NewRegistry()
-	registry.MustRegister(remoteConfigurationsMetric)
-	r.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{})).Methods("GET")
-
 	r.Handle(APIPrefix+"/gathering_rules", gatheringRulesEndpoint(s.svc)).Methods("GET")
 	r.HandleFunc(APIPrefix+V1Prefix+"/openapi.json", serveOpenAPI).Methods("GET")
 	r.Handle(APIPrefix+V1Prefix+"/gathering_rules", gatheringRulesEndpoint(s.svc)).Methods("GET")
@@ -61,4 +88,45 @@ func (s *Handler) Register(r *mux.Router) {
 
 	v2Path := fmt.Sprintf("%s%s/{ocpVersion}/gathering_rules", APIPrefix, V2Prefix)
 	r.Handle(v2Path, remoteConfigurationEndpoint(s.svc)).Methods("GET")
+	r.Handle(APIPrefix+V2Prefix+EvaluatePath, evaluationEndpoint(s.svc)).Methods("POST")
+	r.Handle(APIPrefix+V2Prefix+FeedbackPath, feedbackEndpoint(s.svc)).Methods("POST")
+}
+
+// RegisterMonitoring function registers the metrics, health and readiness
+// endpoints. These are served by the admin listener when it is configured,
+// otherwise by the public one.
+func (s *Handler) RegisterMonitoring(r *mux.Router) {
+	r.Handle(MetricsPath, metricsHandler()).Methods("GET")
+	r.HandleFunc(HealthPath, healthEndpoint).Methods("GET")
+	r.Handle(ReadinessPath, readinessEndpoint(s.svc)).Methods("GET")
+}
+
+// RegisterAdmin function registers the read-only admin endpoints. They expose
+// the data of all the clusters, so they must be registered only on the admin
+// listener.
+func (s *Handler) RegisterAdmin(r *mux.Router) {
+	r.Handle(ExpiredPath, expiredEntriesEndpoint(s.svc)).Methods("GET")
+	r.Handle(DenyListPath, denyListEndpoint(s.svc)).Methods("GET")
+	r.Handle(ReleasesPath, releaseStateEndpoint(s.svc)).Methods("GET")
+	r.Handle(LedgerPath, ledgerEndpoint(s.svc)).Methods("GET")
+	r.Handle(UnmappedVersionsPath, unmappedVersionsEndpoint(s.svc)).Methods("GET")
+}
+
+// RegisterOperations function registers the admin endpoints changing the
+// served rules. They must be registered only on the admin listener, every
+// request needs the token of the operations.
+func (s *Handler) RegisterOperations(r *mux.Router, token string) {
+	r.Handle(DenyListPath, operationsAuthentication(token, updateDenyListEndpoint(s.svc))).Methods("PUT")
+	r.Handle(ReleasesPath+"/{operation}", operationsAuthentication(token, updateReleaseEndpoint(s.svc))).Methods("POST")
+}
+
+// RegisterDebug function registers the profiling endpoints. They must be
+// registered only on the admin listener.
+func (s *Handler) RegisterDebug(r *mux.Router) {
+	debug := r.PathPrefix(DebugPrefix).Subrouter()
+	debug.HandleFunc("/cmdline", pprof.Cmdline).Methods("GET")
+	debug.HandleFunc("/profile", pprof.Profile).Methods("GET")
+	debug.HandleFunc("/symbol", pprof.Symbol).Methods("GET", "POST")
+	debug.HandleFunc("/trace", pprof.Trace).Methods("GET")
+	debug.PathPrefix("/").Handler(http.HandlerFunc(pprof.Index)).Methods("GET")
 }
@@ -20,11 +20,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWrongMethods(t *testing.T) {
@@ -67,3 +69,114 @@ func TestWrongMethods(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterAdmin(t *testing.T) {
+	store := mockStorage{
+		remoteConfig:         []byte(configDefaultConfiguration),
+		remoteConfigFilepath: "config_default.json",
+	}
+	handler := service.NewHandler(service.New(service.NewRepository(&store)))
+	router := mux.NewRouter()
+	handler.RegisterMonitoring(router)
+	handler.RegisterAdmin(router)
+	handler.RegisterDebug(router)
+
+	endpoints := []string{
+		service.MetricsPath,
+		service.HealthPath,
+		service.ReadinessPath,
+		service.ExpiredPath,
+		service.DenyListPath,
+		service.ReleasesPath,
+		service.UnmappedVersionsPath,
+		service.DebugPrefix + "/",
+		service.DebugPrefix + "/cmdline",
+	}
+
+	for _, endpoint := range endpoints {
+		t.Run(endpoint, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, endpoint, http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+
+	t.Run("metrics are not served by the public router", func(t *testing.T) {
+		publicRouter := mux.NewRouter()
+		handler.Register(publicRouter)
+
+		req, err := http.NewRequest(http.MethodGet, service.MetricsPath, http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		publicRouter.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	// without the admin listener only the monitoring endpoints are served by
+	// the public listener
+	publicRouter := mux.NewRouter()
+	handler.Register(publicRouter)
+	handler.RegisterMonitoring(publicRouter)
+	for _, endpoint := range []string{
+		service.ExpiredPath,
+		service.DenyListPath,
+		service.ReleasesPath,
+		service.LedgerPath,
+		service.UnmappedVersionsPath,
+	} {
+		t.Run("public "+endpoint, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			publicRouter.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, endpoint, http.NoBody))
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	}
+}
+
+// TestReadinessEndpoint checks the service is not ready without the remote
+// configurations and the ledger doesn't affect the readiness
+func TestReadinessEndpoint(t *testing.T) {
+	config := service.LedgerConfig{DSN: filepath.Join(t.TempDir(), "ledger.db")}
+	store, err := service.OpenLedgerStore(config)
+	require.NoError(t, err)
+	closedLedger := service.NewLedger(store, config)
+	require.NoError(t, closedLedger.Close())
+
+	testCases := []struct {
+		name           string
+		storage        mockStorage
+		ledger         *service.Ledger
+		expectedStatus int
+	}{
+		{"ready", mockStorage{remoteConfigFilepath: "config_default.json"}, nil, http.StatusOK},
+		{"no remote configuration", mockStorage{}, nil, http.StatusServiceUnavailable},
+		{"ledger closed", mockStorage{remoteConfigFilepath: "config_default.json"}, closedLedger, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := service.NewRepository(&tc.storage)
+			if tc.ledger != nil {
+				repo.SetLedger(tc.ledger)
+			}
+			router := mux.NewRouter()
+			service.NewHandler(service.New(repo)).RegisterMonitoring(router)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, service.ReadinessPath, http.NoBody))
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+
+			// the liveness probe doesn't depend on the readiness
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, service.HealthPath, http.NoBody))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
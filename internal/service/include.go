@@ -0,0 +1,190 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// IncludeKey is the key of the remote configuration listing the fragments
+// the configuration is composed of
+const IncludeKey = "$include"
+
+// includeResolver composes the remote configurations with the fragments
+// they include. The resolved files are kept, so every file is read once.
+type includeResolver struct {
+	read     func(path string) ([]byte, error)
+	resolved map[string]map[string]interface{}
+	// composed holds the resolved files including any fragment
+	composed map[string]bool
+	// stack holds the files being resolved, it's used to detect cycles
+	stack []string
+}
+
+func newIncludeResolver(read func(path string) ([]byte, error)) *includeResolver {
+	return &includeResolver{
+		read:     read,
+		resolved: map[string]map[string]interface{}{},
+		composed: map[string]bool{},
+	}
+}
+
+// Resolve returns the content of the file composed with all the fragments
+// it includes. It returns nil when the file includes no fragment, so its
+// content can be used as it is.
+func (r *includeResolver) Resolve(path string) ([]byte, error) {
+	object, err := r.resolve(path)
+	if err != nil || !r.composed[path] {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+func (r *includeResolver) resolve(path string) (map[string]interface{}, error) {
+	if object, found := r.resolved[path]; found {
+		return object, nil
+	}
+	if i := slices.Index(r.stack, path); i >= 0 {
+		cycle := append(slices.Clone(r.stack[i:]), path)
+		return nil, &merrors.CorruptDataError{
+			Path: r.stack[0],
+			Err:  fmt.Errorf("the includes make a cycle: %s", strings.Join(cycle, " -> ")),
+		}
+	}
+	r.stack = append(r.stack, path)
+	defer func() {
+		r.stack = r.stack[:len(r.stack)-1]
+	}()
+
+	data, err := r.read(path)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, &merrors.CorruptDataError{Path: path, Err: err}
+	}
+
+	includes, err := includedFragments(path, object[IncludeKey])
+	if err != nil {
+		return nil, &merrors.CorruptDataError{Path: path, Err: err}
+	}
+	delete(object, IncludeKey)
+	if len(includes) == 0 {
+		r.resolved[path] = object
+		return object, nil
+	}
+
+	// the fragments are merged in their order, the file itself is the last
+	composed := map[string]interface{}{}
+	for _, include := range includes {
+		fragment, err := r.resolve(include)
+		if err != nil {
+			return nil, err
+		}
+		composed = mergeJSONObjects(composed, fragment)
+	}
+	composed = mergeJSONObjects(composed, object)
+
+	r.resolved[path] = composed
+	r.composed[path] = true
+	return composed, nil
+}
+
+// includedFragments returns the paths of the fragments listed by the
+// $include value of the file
+func includedFragments(file string, value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New(IncludeKey + " must be a list of files")
+	}
+
+	paths := make([]string, 0, len(items))
+	for _, item := range items {
+		include, ok := item.(string)
+		if !ok || !filepath.IsLocal(include) {
+			return nil, fmt.Errorf("%s entry '%v' is not a local file", IncludeKey, item)
+		}
+		paths = append(paths, filepath.Join(filepath.Dir(file), include))
+	}
+	return paths, nil
+}
+
+// mergeJSONObjects returns the base object with the override merged in. The
+// nested objects are merged, the lists are concatenated and the other
+// values of the override replace the base ones. An object of a list with an
+// "id" replaces the object with the same "id" of the base list.
+func mergeJSONObjects(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range override {
+		switch overrideValue := value.(type) {
+		case map[string]interface{}:
+			if baseValue, ok := merged[key].(map[string]interface{}); ok {
+				merged[key] = mergeJSONObjects(baseValue, overrideValue)
+				continue
+			}
+		case []interface{}:
+			if baseValue, ok := merged[key].([]interface{}); ok {
+				merged[key] = mergeJSONLists(baseValue, overrideValue)
+				continue
+			}
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// mergeJSONLists returns the base list followed by the items of the
+// override. The items with the "id" of a base item replace it instead and
+// the items equal to a base item are skipped, so a fragment included twice
+// adds its items once.
+func mergeJSONLists(base, override []interface{}) []interface{} {
+	merged := slices.Clone(base)
+	for _, item := range override {
+		if slices.ContainsFunc(merged, func(baseItem interface{}) bool {
+			return reflect.DeepEqual(baseItem, item)
+		}) {
+			continue
+		}
+		id, hasID := fieldOf(item, "id").(string)
+		i := slices.IndexFunc(merged, func(baseItem interface{}) bool {
+			baseID, ok := fieldOf(baseItem, "id").(string)
+			return hasID && ok && baseID == id
+		})
+		if i >= 0 {
+			merged[i] = item
+			continue
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
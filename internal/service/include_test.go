@@ -0,0 +1,108 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+// TestStorageIncludes checks the remote configuration is composed with the
+// fragments it includes
+func TestStorageIncludes(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: "testdata/v2_includes",
+	}, false, nil)
+	require.NoError(t, err)
+
+	data, err := storage.ReadRemoteConfig(context.Background(), "testdata/v2_includes/stable/config.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "1.0.0",
+		"conditional_gathering_rules": [
+			{
+				"id": "pod-not-ready",
+				"conditions": [{"type":"alert_is_firing","alert":{"name":"KubePodNotReady"}}],
+				"gathering_functions": {"containers_logs":{"alert_name":"KubePodNotReady","tail_lines":50}}
+			},
+			{
+				"conditions": [{"type":"alert_is_firing","alert":{"name":"KubePodCrashLooping"}}],
+				"gathering_functions": {"containers_logs":{"alert_name":"KubePodCrashLooping","tail_lines":20}}
+			},
+			{
+				"id": "samples-import-failing",
+				"conditions": [{"type":"alert_is_firing","alert":{"name":"SamplesImagestreamImportFailing"}}],
+				"gathering_functions": {"logs_of_namespace":{"namespace":"openshift-cluster-samples-operator","tail_lines":100}}
+			}
+		],
+		"container_logs": [
+			{"namespace":"openshift-monitoring","pod_name_regex":"prometheus-k8s-.*","messages":["error"]}
+		]
+	}`, string(data))
+
+	// the files without includes are served as they are
+	data, err = storage.ReadRemoteConfig(context.Background(), "testdata/v2_includes/canary/config.json")
+	require.NoError(t, err)
+	expected, err := os.ReadFile("testdata/v2_includes/canary/config.json")
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+}
+
+func TestStorageInvalidIncludes(t *testing.T) {
+	_, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: "testdata/v2_include_cycle",
+	}, false, nil)
+	var corruptErr *merrors.CorruptDataError
+	require.ErrorAs(t, err, &corruptErr)
+	assert.ErrorContains(t, err, "the includes make a cycle: testdata/v2_include_cycle/stable/first.json -> "+
+		"testdata/v2_include_cycle/stable/second.json -> testdata/v2_include_cycle/stable/first.json")
+
+	testCases := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{"not a list", `{"$include": "base.json"}`, "$include must be a list of files"},
+		{"not local", `{"$include": ["../base.json"]}`, "$include entry '../base.json' is not a local file"},
+		{"missing fragment", `{"$include": ["missing.json"]}`, "missing.json"},
+		{"corrupt fragment", `{"$include": ["corrupt.json"]}`, "corrupt.json' are corrupt"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, channel := range []string{service.StableVersion, service.CanaryVersion} {
+				channelDir := filepath.Join(dir, channel)
+				require.NoError(t, os.Mkdir(channelDir, 0o700))
+				require.NoError(t, os.WriteFile(filepath.Join(channelDir, "cluster_version_mapping.json"), []byte(`[["4.10.0", "config.json"]]`), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(channelDir, "config.json"), []byte(tc.config), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(channelDir, "corrupt.json"), []byte(`{"version":`), 0o600))
+			}
+
+			_, err := service.NewStorage(service.StorageConfig{RemoteConfigurationsPath: dir}, false, nil)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
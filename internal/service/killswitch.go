@@ -0,0 +1,234 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/files"
+)
+
+// kinds of the deny-list entries
+const (
+	denyGatheringFunction = "gathering_function"
+	denyConditionType     = "condition_type"
+	denyAlertName         = "alert_name"
+	denyRuleID            = "rule_id"
+)
+
+// DenyList structure represents the gathering functions, condition types,
+// alert names and rule IDs that must not be served. A rule is denied when
+// it matches any of the entries.
+type DenyList struct {
+	GatheringFunctions []string `json:"gathering_functions"`
+	ConditionTypes     []string `json:"condition_types"`
+	AlertNames         []string `json:"alert_names"`
+	RuleIDs            []string `json:"rule_ids"`
+}
+
+// entries returns the entries of the deny-list by their kinds
+func (d DenyList) entries() map[string][]string {
+	return map[string][]string{
+		denyGatheringFunction: d.GatheringFunctions,
+		denyConditionType:     d.ConditionTypes,
+		denyAlertName:         d.AlertNames,
+		denyRuleID:            d.RuleIDs,
+	}
+}
+
+// Validate checks the deny-list has no empty entry
+func (d DenyList) Validate() error {
+	for kind, names := range d.entries() {
+		if slices.Contains(names, "") {
+			return &merrors.ValidationError{
+				ParamName:  kind,
+				ParamValue: "",
+				ErrString:  "empty entries are not allowed"}
+		}
+	}
+	return nil
+}
+
+// normalize replaces the missing lists by empty ones
+func (d DenyList) normalize() DenyList {
+	for _, names := range []*[]string{&d.GatheringFunctions, &d.ConditionTypes, &d.AlertNames, &d.RuleIDs} {
+		if *names == nil {
+			*names = []string{}
+		}
+	}
+	return d
+}
+
+// Denies returns true when the rule matches any entry of the deny-list
+func (d DenyList) Denies(rule Rule) bool {
+	if len(d.RuleIDs) > 0 && slices.Contains(d.RuleIDs, rule.RuleID()) {
+		return true
+	}
+	for name := range fieldsOf(rule.GatheringFunctions) {
+		if slices.Contains(d.GatheringFunctions, name) {
+			return true
+		}
+	}
+	return slices.ContainsFunc(rule.Conditions, func(condition interface{}) bool {
+		conditionType, _ := fieldOf(condition, "type").(string)
+		alertName, _ := fieldOf(fieldOf(condition, "alert"), "name").(string)
+		return slices.Contains(d.ConditionTypes, conditionType) ||
+			(alertName != "" && slices.Contains(d.AlertNames, alertName))
+	})
+}
+
+// KillSwitch holds the deny-list applied to all the served rules. The
+// deny-list is read from a file, if configured, which is reloaded when it
+// changes. The updates of the deny-list are written to the file.
+type KillSwitch struct {
+	path     string
+	mutex    sync.RWMutex
+	denyList DenyList
+	watcher  *files.Watcher
+}
+
+// NewKillSwitch constructs new kill switch reading the deny-list from the
+// file. The deny-list is kept only in memory when the path is empty, a
+// missing file means an empty deny-list.
+func NewKillSwitch(path string) (*KillSwitch, error) {
+	k := &KillSwitch{path: path}
+	if path == "" {
+		k.setDenyListLocked(DenyList{})
+		return k, nil
+	}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// DenyList returns the current deny-list
+func (k *KillSwitch) DenyList() DenyList {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.denyList
+}
+
+// Update replaces the deny-list and writes it to the file. The lock is held
+// until the deny-list is replaced, so concurrent updates are serialized and
+// the deny-list in memory is the one written last.
+func (k *KillSwitch) Update(denyList DenyList) error {
+	if err := denyList.Validate(); err != nil {
+		return err
+	}
+	denyList = denyList.normalize()
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.path != "" {
+		data, err := json.MarshalIndent(denyList, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := files.WriteAtomic(k.path, data, 0o600); err != nil {
+			return err
+		}
+	}
+
+	k.setDenyListLocked(denyList)
+	log.Info().Interface("denyList", denyList).Msg("Deny-list updated")
+	return nil
+}
+
+// Reload reads the deny-list from the file again
+func (k *KillSwitch) Reload() error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	data, err := os.ReadFile(k.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		k.setDenyListLocked(DenyList{})
+		return nil
+	}
+	if err != nil {
+		return &merrors.StorageIOError{Path: k.path, Err: err}
+	}
+
+	var denyList DenyList
+	if err := json.Unmarshal(data, &denyList); err != nil {
+		return &merrors.CorruptDataError{Path: k.path, Err: err}
+	}
+	if err := denyList.Validate(); err != nil {
+		return &merrors.CorruptDataError{Path: k.path, Err: err}
+	}
+	k.setDenyListLocked(denyList)
+	return nil
+}
+
+// setDenyListLocked replaces the deny-list and updates the metric of its
+// entries. The caller must hold the lock.
+func (k *KillSwitch) setDenyListLocked(denyList DenyList) {
+	k.denyList = denyList.normalize()
+
+	denyListMetric.Reset()
+	for kind, names := range k.denyList.entries() {
+		for _, name := range names {
+			denyListMetric.WithLabelValues(kind, name).Set(1)
+		}
+	}
+}
+
+// Watch starts reloading the deny-list when its file changes
+func (k *KillSwitch) Watch() error {
+	if k.path == "" {
+		return nil
+	}
+	watcher, err := files.WatchDirs("deny-list", []string{filepath.Dir(k.path)}, k.Reload)
+	if err != nil {
+		return err
+	}
+	k.watcher = watcher
+	return nil
+}
+
+// Close stops watching the deny-list file
+func (k *KillSwitch) Close() error {
+	return k.watcher.Close()
+}
+
+// Apply returns the rules not denied by the deny-list and the number of the
+// denied ones
+func (k *KillSwitch) Apply(rules []Rule) ([]Rule, int) {
+	if rules == nil {
+		return nil, 0
+	}
+	denyList := k.DenyList()
+
+	allowed := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if denyList.Denies(rule) {
+			continue
+		}
+		allowed = append(allowed, rule)
+	}
+	return allowed, len(rules) - len(allowed)
+}
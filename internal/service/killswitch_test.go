@@ -0,0 +1,191 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestDenyListDenies(t *testing.T) {
+	rule := alertRule("KubePodNotReady", 10)
+	rule.ID = "pod-not-ready"
+
+	testCases := []struct {
+		name     string
+		denyList service.DenyList
+		expected bool
+	}{
+		{"empty deny-list", service.DenyList{}, false},
+		{"gathering function", service.DenyList{GatheringFunctions: []string{"containers_logs"}}, true},
+		{"other gathering function", service.DenyList{GatheringFunctions: []string{"pod_definition"}}, false},
+		{"condition type", service.DenyList{ConditionTypes: []string{service.AlertIsFiringCondition}}, true},
+		{"alert name", service.DenyList{AlertNames: []string{"KubePodNotReady"}}, true},
+		{"other alert name", service.DenyList{AlertNames: []string{"Watchdog"}}, false},
+		{"rule ID", service.DenyList{RuleIDs: []string{"pod-not-ready"}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.denyList.Denies(rule))
+		})
+	}
+}
+
+// TestKillSwitchFile checks the deny-list is read from the file, written to
+// it and reloaded when the file changes
+func TestKillSwitchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny_list.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"gathering_functions":["logs_of_namespace"]}`), 0o600))
+
+	killSwitch, err := service.NewKillSwitch(path)
+	require.NoError(t, err)
+	require.NoError(t, killSwitch.Watch())
+	defer func() {
+		assert.NoError(t, killSwitch.Close())
+	}()
+	assert.Equal(t, []string{"logs_of_namespace"}, killSwitch.DenyList().GatheringFunctions)
+	assert.Equal(t, 1.0, testutil.ToFloat64(service.DenyListMetric.WithLabelValues("gathering_function", "logs_of_namespace")))
+
+	require.NoError(t, killSwitch.Update(service.DenyList{AlertNames: []string{"KubePodNotReady"}}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"gathering_functions":[],"condition_types":[],"alert_names":["KubePodNotReady"],"rule_ids":[]}`, string(data))
+	assert.Equal(t, 1, testutil.CollectAndCount(service.DenyListMetric))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rule_ids":["pod-not-ready"]}`), 0o600))
+	assert.Eventually(t, func() bool {
+		denyList := killSwitch.DenyList()
+		return len(denyList.RuleIDs) == 1 && len(denyList.AlertNames) == 0
+	}, 2*time.Second, 50*time.Millisecond)
+
+	_, err = service.NewKillSwitch(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rule_ids":[""]}`), 0o600))
+	_, err = service.NewKillSwitch(path)
+	var corruptErr *merrors.CorruptDataError
+	assert.ErrorAs(t, err, &corruptErr)
+}
+
+// TestKillSwitchConcurrentUpdates checks the deny-list in memory is the one
+// written to the file when it's updated concurrently
+func TestKillSwitchConcurrentUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny_list.json")
+	killSwitch, err := service.NewKillSwitch(path)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, killSwitch.Update(service.DenyList{RuleIDs: []string{fmt.Sprintf("rule-%d", i)}}))
+		}()
+	}
+	wg.Wait()
+
+	reloaded, err := service.NewKillSwitch(path)
+	require.NoError(t, err)
+	assert.Equal(t, killSwitch.DenyList(), reloaded.DenyList())
+}
+
+func TestDenyListEndpoints(t *testing.T) {
+	store := mockStorage{
+		remoteConfig:         []byte(configDefaultConfiguration),
+		remoteConfigFilepath: "config_default.json",
+	}
+	svc := service.New(service.NewRepository(&store))
+	handler := service.NewHandler(svc)
+	router := mux.NewRouter()
+	handler.Register(router)
+	handler.RegisterAdmin(router)
+	handler.RegisterOperations(router, operationsToken)
+
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "empty deny-list",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"gathering_functions":[],"condition_types":[],"alert_names":[],"rule_ids":[]}`,
+		},
+		{
+			name:           "deny-list is replaced",
+			method:         http.MethodPut,
+			body:           `{"gathering_functions":["logs_of_namespace"],"alert_names":["KubePodNotReady"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"gathering_functions":["logs_of_namespace"],"condition_types":[],"alert_names":["KubePodNotReady"],"rule_ids":[]}`,
+		},
+		{
+			name:           "invalid body",
+			method:         http.MethodPut,
+			body:           `{"gathering_functions":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty entry",
+			method:         http.MethodPut,
+			body:           `{"rule_ids":[""]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, service.DenyListPath, strings.NewReader(tc.body))
+			req.Header.Set(service.OperationsTokenHeader, operationsToken)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+
+	// the denied rules are not served
+	req := httptest.NewRequest(http.MethodGet, "/v2/4.17.0/gathering_rules", http.NoBody)
+	remoteConfig, err := svc.RemoteConfiguration(req, "4.17.0")
+	require.NoError(t, err)
+	assert.Len(t, remoteConfig.ConditionalRules, 7)
+	for _, rule := range remoteConfig.ConditionalRules {
+		assert.NotContains(t, rule.GatheringFunctions, "logs_of_namespace")
+		assert.NotContains(t, rule.GatheringFunctions, "pod_definition")
+	}
+}
@@ -0,0 +1,479 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// drivers of the ledger stores
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog/log"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// Drivers of the ledger stores
+const (
+	// LedgerSQLite stores the records in the SQLite database file given by
+	// the DSN
+	LedgerSQLite = "sqlite"
+	// LedgerPostgres stores the records in the PostgreSQL database given by
+	// the DSN
+	LedgerPostgres = "postgres"
+)
+
+// Query parameters of the ledger endpoint
+const (
+	ClusterIDParam  = "cluster_id"
+	OCPVersionParam = "ocp_version"
+	VersionParam    = "version"
+	FromParam       = "from"
+	ToParam         = "to"
+	LimitParam      = "limit"
+)
+
+const (
+	defaultLedgerQueueSize = 1000
+	defaultLedgerLimit     = 100
+	maxLedgerLimit         = 1000
+	ledgerBatchSize        = 100
+	ledgerCleanupInterval  = time.Hour
+	ledgerPingInterval     = time.Minute
+	ledgerTimeout          = 10 * time.Second
+)
+
+// outcomes of the ledger records
+const (
+	ledgerRecordWritten = "written"
+	ledgerRecordDropped = "dropped"
+	ledgerRecordFailed  = "failed"
+)
+
+// ledgerSchema creates the table of the records. The times are stored as
+// Unix milliseconds, so they compare the same in all the databases.
+var ledgerSchema = []string{
+	`CREATE TABLE IF NOT EXISTS served_configurations (
+		cluster_id TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		ocp_version TEXT NOT NULL,
+		file TEXT NOT NULL,
+		version TEXT NOT NULL,
+		served_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS served_configurations_cluster_id ON served_configurations (cluster_id, served_at)`,
+	`CREATE INDEX IF NOT EXISTS served_configurations_served_at ON served_configurations (served_at)`,
+}
+
+// LedgerConfig structure contains configuration of the ledger of the
+// served remote configurations
+type LedgerConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled"`
+	// Driver is sqlite, the default, or postgres
+	Driver string `mapstructure:"driver" toml:"driver"`
+	// DSN is the path of the SQLite database file or the PostgreSQL
+	// connection string
+	DSN string `mapstructure:"dsn" toml:"dsn"`
+	// Retention is the age of the deleted records, the records are kept
+	// forever when it's zero
+	Retention time.Duration `mapstructure:"retention" toml:"retention"`
+	// QueueSize is the number of records waiting to be written, the new
+	// records are dropped when the queue is full
+	QueueSize int `mapstructure:"queue_size" toml:"queue_size"`
+}
+
+// LedgerRecord structure represents a remote configuration served to a
+// cluster
+type LedgerRecord struct {
+	ClusterID  string `json:"cluster_id"`
+	Channel    string `json:"channel"`
+	OCPVersion string `json:"ocp_version"`
+	File       string `json:"file"`
+	// Version is the version of the remote configuration
+	Version  string    `json:"version"`
+	ServedAt time.Time `json:"served_at"`
+}
+
+// LedgerResponse structure represents HTTP response with the records of the
+// ledger
+type LedgerResponse struct {
+	Records []LedgerRecord `json:"records"`
+}
+
+// LedgerFilter selects the records of the ledger. The empty criteria match
+// all the records.
+type LedgerFilter struct {
+	ClusterID  string
+	OCPVersion string
+	Version    string
+	// From is the first and To the end of the time range
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// ParseLedgerFilter reads the filter from the query parameters. The times
+// are in the RFC 3339 format.
+func ParseLedgerFilter(query url.Values) (LedgerFilter, error) {
+	filter := LedgerFilter{
+		ClusterID:  query.Get(ClusterIDParam),
+		OCPVersion: query.Get(OCPVersionParam),
+		Version:    query.Get(VersionParam),
+		Limit:      defaultLedgerLimit,
+	}
+
+	for param, value := range map[string]*time.Time{FromParam: &filter.From, ToParam: &filter.To} {
+		if raw := query.Get(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return LedgerFilter{}, &merrors.ValidationError{
+					ParamName:  param,
+					ParamValue: raw,
+					ErrString:  "expected a time like 2026-01-31T12:00:00Z"}
+			}
+			*value = parsed
+		}
+	}
+
+	if raw := query.Get(LimitParam); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxLedgerLimit {
+			return LedgerFilter{}, &merrors.ValidationError{
+				ParamName:  LimitParam,
+				ParamValue: raw,
+				ErrString:  fmt.Sprintf("expected a number from 1 to %d", maxLedgerLimit)}
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// LedgerStore defines methods to be implemented by the stores of the
+// ledger records
+type LedgerStore interface {
+	Insert(ctx context.Context, records []LedgerRecord) error
+	// Query returns the selected records, the latest first
+	Query(ctx context.Context, filter LedgerFilter) ([]LedgerRecord, error)
+	// DeleteBefore deletes the records served before the time and returns
+	// their number
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// Ping checks the store is reachable
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// sqlLedgerStore stores the records in a SQL database
+type sqlLedgerStore struct {
+	db *sql.DB
+}
+
+// OpenLedgerStore opens the database of the configured driver and creates
+// the table of the records
+func OpenLedgerStore(config LedgerConfig) (LedgerStore, error) {
+	var driver string
+	switch config.Driver {
+	case "", LedgerSQLite:
+		driver = "sqlite3"
+	case LedgerPostgres:
+		driver = "postgres"
+	default:
+		return nil, fmt.Errorf("unknown ledger driver '%s'", config.Driver)
+	}
+
+	db, err := sql.Open(driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite3" {
+		// SQLite allows one writer, so the queries wait for the writes
+		// instead of failing
+		db.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ledgerTimeout)
+	defer cancel()
+	for _, statement := range ledgerSchema {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("cannot create the ledger table: %w", err)
+		}
+	}
+	return &sqlLedgerStore{db: db}, nil
+}
+
+func (s *sqlLedgerStore) Insert(ctx context.Context, records []LedgerRecord) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	statement, err := tx.PrepareContext(ctx, `INSERT INTO served_configurations
+		(cluster_id, channel, ocp_version, file, version, served_at) VALUES ($1, $2, $3, $4, $5, $6)`)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	for _, record := range records {
+		_, err := statement.ExecContext(ctx, record.ClusterID, record.Channel, record.OCPVersion,
+			record.File, record.Version, record.ServedAt.UnixMilli())
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqlLedgerStore) Query(ctx context.Context, filter LedgerFilter) ([]LedgerRecord, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ClusterID != "" {
+		where("cluster_id = $%d", filter.ClusterID)
+	}
+	if filter.OCPVersion != "" {
+		where("ocp_version = $%d", filter.OCPVersion)
+	}
+	if filter.Version != "" {
+		where("version = $%d", filter.Version)
+	}
+	if !filter.From.IsZero() {
+		where("served_at >= $%d", filter.From.UnixMilli())
+	}
+	if !filter.To.IsZero() {
+		where("served_at < $%d", filter.To.UnixMilli())
+	}
+
+	query := "SELECT cluster_id, channel, ocp_version, file, version, served_at FROM served_configurations"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY served_at DESC LIMIT %d", filter.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []LedgerRecord{}
+	for rows.Next() {
+		var (
+			record   LedgerRecord
+			servedAt int64
+		)
+		err := rows.Scan(&record.ClusterID, &record.Channel, &record.OCPVersion, &record.File, &record.Version, &servedAt)
+		if err != nil {
+			return nil, err
+		}
+		record.ServedAt = time.UnixMilli(servedAt).UTC()
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func (s *sqlLedgerStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM served_configurations WHERE served_at < $1", before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *sqlLedgerStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *sqlLedgerStore) Close() error {
+	return s.db.Close()
+}
+
+// Ledger records the remote configurations served to the clusters. The
+// records are written to the store in the background, so the requests
+// don't wait for the database.
+type Ledger struct {
+	store     LedgerStore
+	retention time.Duration
+	records   chan LedgerRecord
+	stop      chan struct{}
+	done      chan struct{}
+
+	// mutex guards closed, the records are never queued once it's set
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// NewLedger constructs new ledger writing the records to the store and
+// starts deleting the records older than the retention
+func NewLedger(store LedgerStore, config LedgerConfig) *Ledger {
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultLedgerQueueSize
+	}
+	l := &Ledger{
+		store:     store,
+		retention: config.Retention,
+		records:   make(chan LedgerRecord, queueSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Record queues the record to be written. The record is dropped when the
+// queue is full or the ledger is closed.
+func (l *Ledger) Record(record LedgerRecord) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if l.closed {
+		ledgerRecordsMetric.WithLabelValues(ledgerRecordDropped).Inc()
+		return
+	}
+	select {
+	case l.records <- record:
+	default:
+		ledgerRecordsMetric.WithLabelValues(ledgerRecordDropped).Inc()
+	}
+}
+
+// Query returns the selected records of the store
+func (l *Ledger) Query(ctx context.Context, filter LedgerFilter) ([]LedgerRecord, error) {
+	return l.store.Query(ctx, filter)
+}
+
+// Close writes the queued records and closes the store. The records of the
+// requests still running, like after a timed out shutdown, are dropped then.
+func (l *Ledger) Close() error {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mutex.Unlock()
+
+	close(l.stop)
+	<-l.done
+	ledgerAvailableMetric.Set(0)
+	return l.store.Close()
+}
+
+func (l *Ledger) run() {
+	defer close(l.done)
+	cleanup := time.NewTicker(ledgerCleanupInterval)
+	defer cleanup.Stop()
+	ping := time.NewTicker(ledgerPingInterval)
+	defer ping.Stop()
+
+	l.ping()
+	l.deleteExpired()
+	for {
+		select {
+		case record := <-l.records:
+			l.write(l.batch(record))
+		case <-cleanup.C:
+			l.deleteExpired()
+		case <-ping.C:
+			l.ping()
+		case <-l.stop:
+			// nothing is queued after the ledger is closed, so the queue
+			// can be drained
+			for len(l.records) > 0 {
+				l.write(l.batch(<-l.records))
+			}
+			return
+		}
+	}
+}
+
+// batch returns the record with the other queued ones, up to the batch size
+func (l *Ledger) batch(first LedgerRecord) []LedgerRecord {
+	batch := []LedgerRecord{first}
+	for len(batch) < ledgerBatchSize {
+		select {
+		case record := <-l.records:
+			batch = append(batch, record)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (l *Ledger) write(records []LedgerRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), ledgerTimeout)
+	defer cancel()
+	err := l.store.Insert(ctx, records)
+	setLedgerAvailable(err)
+	if err != nil {
+		log.Error().Err(err).Int("records", len(records)).Msg("Cannot write the ledger records")
+		ledgerRecordsMetric.WithLabelValues(ledgerRecordFailed).Add(float64(len(records)))
+		return
+	}
+	ledgerRecordsMetric.WithLabelValues(ledgerRecordWritten).Add(float64(len(records)))
+}
+
+// ping checks the store is reachable when no records are written
+func (l *Ledger) ping() {
+	ctx, cancel := context.WithTimeout(context.Background(), ledgerTimeout)
+	defer cancel()
+	err := l.store.Ping(ctx)
+	setLedgerAvailable(err)
+	if err != nil {
+		log.Error().Err(err).Msg("Ledger store is not reachable")
+	}
+}
+
+// setLedgerAvailable reports the outcome of the last access to the store.
+// The ledger is not required for serving the remote configurations, so it's
+// only reported by the metric and not by the readiness probe.
+func setLedgerAvailable(err error) {
+	if err != nil {
+		ledgerAvailableMetric.Set(0)
+		return
+	}
+	ledgerAvailableMetric.Set(1)
+}
+
+func (l *Ledger) deleteExpired() {
+	if l.retention <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ledgerTimeout)
+	defer cancel()
+	deleted, err := l.store.DeleteBefore(ctx, time.Now().Add(-l.retention))
+	if err != nil {
+		log.Error().Err(err).Msg("Cannot delete the expired ledger records")
+		return
+	}
+	log.Debug().Int64("records", deleted).Msg("Expired ledger records deleted")
+}
@@ -0,0 +1,268 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestParseLedgerFilter(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		expected      service.LedgerFilter
+		expectedParam string
+	}{
+		{
+			name:     "empty",
+			query:    "",
+			expected: service.LedgerFilter{Limit: 100},
+		},
+		{
+			name:  "all",
+			query: "cluster_id=abc&ocp_version=4.16.3&version=1.1.0&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z&limit=10",
+			expected: service.LedgerFilter{
+				ClusterID:  "abc",
+				OCPVersion: "4.16.3",
+				Version:    "1.1.0",
+				From:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:         time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+				Limit:      10,
+			},
+		},
+		{name: "invalid time", query: "from=yesterday", expectedParam: service.FromParam},
+		{name: "invalid limit", query: "limit=all", expectedParam: service.LimitParam},
+		{name: "too high limit", query: "limit=5000", expectedParam: service.LimitParam},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+			filter, err := service.ParseLedgerFilter(query)
+			if tc.expectedParam == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, filter)
+				return
+			}
+			var validationErr *merrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.expectedParam, validationErr.ParamName)
+		})
+	}
+}
+
+func TestLedgerStore(t *testing.T) {
+	config := service.LedgerConfig{DSN: filepath.Join(t.TempDir(), "ledger.db")}
+	store, err := service.OpenLedgerStore(config)
+	require.NoError(t, err)
+	defer store.Close()
+
+	first := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []service.LedgerRecord{
+		{ClusterID: "a", Channel: service.StableVersion, OCPVersion: "4.16.3", File: "config_default.json", Version: "1.0.0", ServedAt: first},
+		{ClusterID: "b", Channel: service.CanaryVersion, OCPVersion: "4.17.0", File: "experimental.json", Version: "1.2.0", ServedAt: first.Add(time.Hour)},
+		{ClusterID: "a", Channel: service.StableVersion, OCPVersion: "4.17.0", File: "experimental.json", Version: "1.1.0", ServedAt: first.Add(2 * time.Hour)},
+	}
+	require.NoError(t, store.Insert(context.Background(), records))
+
+	testCases := []struct {
+		name     string
+		filter   service.LedgerFilter
+		expected []service.LedgerRecord
+	}{
+		{"all", service.LedgerFilter{Limit: 100}, []service.LedgerRecord{records[2], records[1], records[0]}},
+		{"cluster", service.LedgerFilter{ClusterID: "a", Limit: 100}, []service.LedgerRecord{records[2], records[0]}},
+		{"OCP version", service.LedgerFilter{OCPVersion: "4.17.0", Limit: 100}, []service.LedgerRecord{records[2], records[1]}},
+		{"version", service.LedgerFilter{Version: "1.2.0", Limit: 100}, []service.LedgerRecord{records[1]}},
+		{
+			name:     "time range",
+			filter:   service.LedgerFilter{From: first.Add(time.Hour), To: first.Add(2 * time.Hour), Limit: 100},
+			expected: []service.LedgerRecord{records[1]},
+		},
+		{"limit", service.LedgerFilter{Limit: 1}, []service.LedgerRecord{records[2]}},
+		{"none", service.LedgerFilter{ClusterID: "c", Limit: 100}, []service.LedgerRecord{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			found, err := store.Query(context.Background(), tc.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, found)
+		})
+	}
+
+	deleted, err := store.DeleteBefore(context.Background(), first.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = service.OpenLedgerStore(service.LedgerConfig{Driver: "mysql"})
+	assert.ErrorContains(t, err, "unknown ledger driver 'mysql'")
+}
+
+// TestLedger checks the queued records are written on close, the expired
+// ones are deleted, the records of a closed ledger are dropped and the
+// availability of the store is reported
+func TestLedger(t *testing.T) {
+	config := service.LedgerConfig{
+		DSN:       filepath.Join(t.TempDir(), "ledger.db"),
+		Retention: 24 * time.Hour,
+	}
+	store, err := service.OpenLedgerStore(config)
+	require.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.Insert(context.Background(), []service.LedgerRecord{
+		{ClusterID: "expired", ServedAt: now.Add(-48 * time.Hour)},
+	}))
+
+	ledger := service.NewLedger(store, config)
+	for _, clusterID := range []string{"a", "b", "c"} {
+		ledger.Record(service.LedgerRecord{ClusterID: clusterID, Channel: service.StableVersion, ServedAt: now})
+	}
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(service.LedgerAvailableMetric) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, ledger.Close())
+	require.NoError(t, ledger.Close())
+	assert.Equal(t, 0.0, testutil.ToFloat64(service.LedgerAvailableMetric))
+
+	dropped := service.LedgerRecordsMetric.WithLabelValues("dropped")
+	initialDropped := testutil.ToFloat64(dropped)
+	ledger.Record(service.LedgerRecord{ClusterID: "late", ServedAt: now})
+	assert.Equal(t, 1.0, testutil.ToFloat64(dropped)-initialDropped)
+
+	store, err = service.OpenLedgerStore(config)
+	require.NoError(t, err)
+	defer store.Close()
+	records, err := store.Query(context.Background(), service.LedgerFilter{Limit: 100})
+	require.NoError(t, err)
+	clusterIDs := []string{}
+	for _, record := range records {
+		clusterIDs = append(clusterIDs, record.ClusterID)
+	}
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, clusterIDs)
+}
+
+// TestLedgerConcurrentClose checks the records of the requests still running
+// when the ledger is closed don't panic
+func TestLedgerConcurrentClose(t *testing.T) {
+	config := service.LedgerConfig{DSN: filepath.Join(t.TempDir(), "ledger.db")}
+	store, err := service.OpenLedgerStore(config)
+	require.NoError(t, err)
+	ledger := service.NewLedger(store, config)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				ledger.Record(service.LedgerRecord{ClusterID: "a", ServedAt: time.Now()})
+			}
+		}()
+	}
+	require.NoError(t, ledger.Close())
+	wg.Wait()
+}
+
+func TestLedgerEndpoint(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: channelParentFolder,
+	}, true, &MockUnleashClient{})
+	require.NoError(t, err)
+	repo := service.NewRepository(storage)
+	router := mux.NewRouter()
+	handler := service.NewHandler(service.New(repo))
+	handler.Register(router)
+	handler.RegisterAdmin(router)
+
+	query := func(t *testing.T, path string) (int, service.LedgerResponse) {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response service.LedgerResponse
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		}
+		return rr.Code, response
+	}
+
+	code, _ := query(t, service.LedgerPath)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	config := service.LedgerConfig{DSN: filepath.Join(t.TempDir(), "ledger.db")}
+	store, err := service.OpenLedgerStore(config)
+	require.NoError(t, err)
+	ledger := service.NewLedger(store, config)
+	defer ledger.Close()
+	repo.SetLedger(ledger)
+
+	req := httptest.NewRequest(http.MethodGet, service.APIPrefix+service.V2Prefix+"/4.12.0/gathering_rules", http.NoBody)
+	req.Header.Set("User-Agent", canaryUserAgent)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// the evaluations of the conditions are not recorded
+	req = httptest.NewRequest(http.MethodPost, service.APIPrefix+service.V2Prefix+service.EvaluatePath,
+		strings.NewReader(`{"cluster_version":"4.16.0"}`))
+	req.Header.Set("User-Agent", canaryUserAgent)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var records []service.LedgerRecord
+	assert.Eventually(t, func() bool {
+		_, response := query(t, service.LedgerPath+"?cluster_id="+canaryClusterID)
+		records = response.Records
+		return len(records) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, records, 1)
+	assert.Equal(t, service.CanaryVersion, records[0].Channel)
+	assert.Equal(t, "4.12.0", records[0].OCPVersion)
+	assert.Equal(t, "config_default.json", records[0].File)
+	assert.Equal(t, "1.0.0", records[0].Version)
+
+	code, _ = query(t, service.LedgerPath+"?limit=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// all the queued records are written on close
+	require.NoError(t, ledger.Close())
+	store, err = service.OpenLedgerStore(config)
+	require.NoError(t, err)
+	defer store.Close()
+	records, err = store.Query(context.Background(), service.LedgerFilter{ClusterID: canaryClusterID, Limit: 100})
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
@@ -1,7 +1,12 @@
 package service
 
 import (
+	"net/http"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
 )
 
 var (
@@ -11,4 +16,140 @@ var (
 			Help: "The number of times a remote configuration was returned",
 		},
 		[]string{"file", "version"})
+
+	overlaysMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_remote_configuration_overlays",
+			Help: "The number of times an overlay was merged into the returned remote configuration by the kind of overlay",
+		},
+		[]string{"kind"})
+
+	expiredEntriesMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "io_gathering_expired_entries",
+			Help: "The number of rules and container logs requests with ended activation window by the file or overlay",
+		},
+		[]string{"source"})
+
+	strippedRulesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_stripped_rules",
+			Help: "The number of rules stripped from the responses by their gathering functions or condition types unsupported by the client",
+		},
+		[]string{"kind", "name"})
+
+	denyListMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "io_gathering_deny_list_entries",
+			Help: "The active entries of the deny-list, set to 1 for every denied gathering function, condition type, alert name and rule ID",
+		},
+		[]string{"kind", "name"})
+
+	releaseSnapshotMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "io_gathering_release_snapshot",
+			Help: "The snapshots served to the clusters of the channels, set to 1 for the served snapshot of every channel",
+		},
+		[]string{"channel", "snapshot"})
+
+	feedbackReportsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_feedback_reports",
+			Help: "The number of feedbacks reported by the clusters by the channel, version of the remote configuration and outcome",
+		},
+		[]string{"channel", "version", "outcome"})
+
+	feedbackGathererErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_feedback_gatherer_errors",
+			Help: "The number of gatherer errors reported by the clusters by the channel, version of the remote configuration and gatherer",
+		},
+		[]string{"channel", "version", "gatherer"})
+
+	feedbackGathererDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "io_gathering_feedback_gatherer_duration_seconds",
+			Help:    "The durations of the gatherers reported by the clusters by the channel and gatherer",
+			Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"channel", "gatherer"})
+
+	feedbackErrorRateMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "io_gathering_feedback_error_rate",
+			Help: "The share of the failed feedbacks of the current guardrail window by the channel",
+		},
+		[]string{"channel"})
+
+	guardrailTripsMetric = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "io_gathering_canary_guardrail_trips",
+			Help: "The number of times the canary snapshot was cleared by the guardrail",
+		})
+
+	fleetVersionsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_fleet_versions",
+			Help: "The number of remote configuration requests by the major.minor OCP version of the cluster and channel",
+		},
+		[]string{"ocp_version", "channel"})
+
+	unmappedVersionsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_unmapped_versions",
+			Help: "The number of remote configuration requests for OCP versions below the cluster mapping or newer than its last entry by the major.minor version, channel and kind",
+		},
+		[]string{"ocp_version", "channel", "kind"})
+
+	ledgerRecordsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_ledger_records",
+			Help: "The number of records of the served remote configurations by the outcome of writing them to the ledger",
+		},
+		[]string{"outcome"})
+
+	ledgerAvailableMetric = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "io_gathering_ledger_available",
+			Help: "1 when the last access to the ledger store succeeded, 0 when it failed or the ledger is closed",
+		})
+
+	storageErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "io_gathering_storage_errors",
+			Help: "The number of failed reads of rules and remote configurations by the kind of failure",
+		},
+		[]string{"kind"})
 )
+
+// kinds of the storage errors
+const (
+	storageErrorNotFound = "not_found"
+	storageErrorCorrupt  = "corrupt"
+	storageErrorIO       = "io"
+)
+
+// metricsHandler returns HTTP handler exposing the service metrics
+func metricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		remoteConfigurationsMetric,
+		overlaysMetric,
+		expiredEntriesMetric,
+		strippedRulesMetric,
+		denyListMetric,
+		releaseSnapshotMetric,
+		feedbackReportsMetric,
+		feedbackGathererErrorsMetric,
+		feedbackGathererDurationMetric,
+		feedbackErrorRateMetric,
+		guardrailTripsMetric,
+		ledgerRecordsMetric,
+		ledgerAvailableMetric,
+		fleetVersionsMetric,
+		unmappedVersionsMetric,
+		storageErrorsMetric,
+		server.RateLimitedRequestsMetric,
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
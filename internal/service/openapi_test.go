@@ -0,0 +1,288 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/api"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// openAPISpec is the subset of the OpenAPI specification used by the tests
+type openAPISpec struct {
+	Paths map[string]map[string]struct {
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema map[string]interface{} `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]map[string]interface{} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPISpec(t *testing.T) *openAPISpec {
+	var spec openAPISpec
+	require.NoError(t, json.Unmarshal(api.Spec, &spec))
+	return &spec
+}
+
+// responseSchema returns the schema of the response described in the spec
+func (spec *openAPISpec) responseSchema(t *testing.T, path, method string, status int, contentType string) map[string]interface{} {
+	operation, found := spec.Paths[path][strings.ToLower(method)]
+	require.True(t, found, "operation %s %s is not described", method, path)
+	response, found := operation.Responses[fmt.Sprint(status)]
+	require.True(t, found, "status %d of %s %s is not described", status, method, path)
+	content, found := response.Content[contentType]
+	require.True(t, found, "content type %s of %s %s is not described", contentType, method, path)
+	return content.Schema
+}
+
+// validate checks the decoded JSON value against the schema. Only the
+// keywords used by the specification are supported.
+func (spec *openAPISpec) validate(schema map[string]interface{}, value interface{}, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		referenced, found := spec.Components.Schemas[strings.TrimPrefix(ref, schemaRefPrefix)]
+		if !found {
+			return []string{fmt.Sprintf("%s: unknown schema %s", at, ref)}
+		}
+		return spec.validate(referenced, value, at)
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, option := range oneOf {
+			if len(spec.validate(option.(map[string]interface{}), value, at)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return []string{fmt.Sprintf("%s: value matches %d of the oneOf schemas", at, matched)}
+		}
+		return nil
+	}
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: null is not allowed", at)}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !slices.Contains(enum, value) {
+		return []string{fmt.Sprintf("%s: %v is not one of %v", at, value, enum)}
+	}
+
+	var problems []string
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not an object", at, value)}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, found := object[name.(string)]; !found {
+				problems = append(problems, fmt.Sprintf("%s: required property %s is missing", at, name))
+			}
+		}
+		for name, property := range object {
+			propertySchema, found := properties[name]
+			if !found {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					problems = append(problems, fmt.Sprintf("%s: unknown property %s", at, name))
+				}
+				continue
+			}
+			problems = append(problems, spec.validate(propertySchema.(map[string]interface{}), property, at+"."+name)...)
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not an array", at, value)}
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			problems = append(problems, spec.validate(items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %T is not a string", at, value))
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != float64(int64(number)) {
+			problems = append(problems, fmt.Sprintf("%s: %v is not an integer", at, value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %T is not a number", at, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %T is not a boolean", at, value))
+		}
+	}
+	return problems
+}
+
+// jsonFields returns the JSON names of the struct fields. The fields of the
+// embedded structs are flattened like by encoding/json.
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// TestOpenAPITypes checks that the response types match the schemas of the
+// OpenAPI specification
+func TestOpenAPITypes(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	testCases := []struct {
+		schema string
+		typ    reflect.Type
+	}{
+		{"GatheringRulesResponse", reflect.TypeFor[service.GatheringRulesResponse]()},
+		{"RemoteConfiguration", reflect.TypeFor[service.RemoteConfiguration]()},
+		{"RulesCountResponse", reflect.TypeFor[service.RulesCountResponse]()},
+		{"GatheringRule", reflect.TypeFor[service.Rule]()},
+		{"ContainerLogRequest", reflect.TypeFor[service.ContainerLogRequest]()},
+		{"ClusterFacts", reflect.TypeFor[service.ClusterFacts]()},
+		{"EvaluationResponse", reflect.TypeFor[service.EvaluationResponse]()},
+		{"GatheringFunctionCall", reflect.TypeFor[service.GatheringFunctionCall]()},
+		{"ConfigurationOrigin", reflect.TypeFor[service.ConfigurationOrigin]()},
+		{"Feedback", reflect.TypeFor[service.Feedback]()},
+		{"GathererFeedback", reflect.TypeFor[service.GathererFeedback]()},
+		{"Problem", reflect.TypeFor[server.Problem]()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.schema, func(t *testing.T) {
+			schema, found := spec.Components.Schemas[tc.schema]
+			require.True(t, found)
+
+			properties, _ := schema["properties"].(map[string]interface{})
+			var names []string
+			for name := range properties {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, names, jsonFields(tc.typ))
+		})
+	}
+}
+
+// TestOpenAPIContract checks the responses of the handlers against the
+// OpenAPI specification
+func TestOpenAPIContract(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+
+	storage, err := service.NewStorage(service.StorageConfig{
+		RulesPath:                "../../tests/conditions",
+		RemoteConfigurationsPath: "../../tests/rapid-recommendations/valid",
+	}, false, nil)
+	require.NoError(t, err)
+	router := mux.NewRouter()
+	service.NewHandler(service.New(service.NewRepository(storage))).Register(router)
+
+	testCases := []struct {
+		method         string
+		url            string
+		body           string
+		specPath       string
+		expectedStatus int
+	}{
+		{http.MethodGet, "/openapi.json", "", "/openapi.json", http.StatusOK},
+		{http.MethodGet, "/v1/openapi.json", "", "/v1/openapi.json", http.StatusOK},
+		{http.MethodGet, "/gathering_rules", "", "/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/v1/gathering_rules", "", "/v1/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/v2/4.17.0/gathering_rules", "", "/v2/{ocpVersion}/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/v2/4.0.0/gathering_rules", "", "/v2/{ocpVersion}/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/gathering_rules?alert_name=KubePodCrashLooping", "", "/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/v1/gathering_rules?count=true", "", "/v1/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/v1/gathering_rules?count=maybe", "", "/v1/gathering_rules", http.StatusBadRequest},
+		{http.MethodGet, "/v2/4.17.0/gathering_rules?gathering_function=unknown", "", "/v2/{ocpVersion}/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/v2/4.17.0/gathering_rules?count=1", "", "/v2/{ocpVersion}/gathering_rules", http.StatusOK},
+		{http.MethodGet, "/v2/not-a-version/gathering_rules", "", "/v2/{ocpVersion}/gathering_rules", http.StatusBadRequest},
+		{http.MethodGet, "/v2/1.2.3/gathering_rules", "", "/v2/{ocpVersion}/gathering_rules", http.StatusNotFound},
+		{http.MethodPost, "/v2/gathering_rules/evaluate", `{"cluster_version":"4.17.0","firing_alerts":["KubePodNotReady"]}`, "/v2/gathering_rules/evaluate", http.StatusOK},
+		{http.MethodPost, "/v2/gathering_rules/evaluate", `{"cluster_version":"4.17"}`, "/v2/gathering_rules/evaluate", http.StatusBadRequest},
+		{http.MethodPost, "/v2/gathering_rules/evaluate", `{"cluster_version":"1.2.3"}`, "/v2/gathering_rules/evaluate", http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, service.APIPrefix+tc.url, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+
+			contentType, _, err := mime.ParseMediaType(rr.Header().Get("Content-Type"))
+			require.NoError(t, err)
+			schema := spec.responseSchema(t, tc.specPath, tc.method, rr.Code, contentType)
+
+			var body interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Empty(t, spec.validate(schema, body, "response"))
+		})
+	}
+}
+
+// TestServeOpenAPI checks that the embedded specification is served
+func TestServeOpenAPI(t *testing.T) {
+	router := mux.NewRouter()
+	service.NewHandler(service.New(service.NewRepository(&mockStorage{}))).Register(router)
+
+	req := httptest.NewRequest(http.MethodGet, service.APIPrefix+"/openapi.json", http.NoBody)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, api.Spec, rr.Body.Bytes())
+}
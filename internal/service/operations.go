@@ -0,0 +1,75 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+)
+
+// OperationsTokenHeader is the request header with the token of the admin
+// operations. It's separate from the Authorization header, so the operations
+// can be served by an admin listener with JWT auth.
+const OperationsTokenHeader = "X-Operations-Token"
+
+// minOperationsTokenLength is the minimal length of the token of the admin
+// operations, so it can't be guessed
+const minOperationsTokenLength = 32
+
+// OperationsConfig structure represents the configuration of the admin
+// operations changing the served rules, like replacing the deny-list or
+// promoting the canary snapshot
+type OperationsConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled"`
+	// Token is required in the OperationsTokenHeader of every operation
+	// request
+	Token string `mapstructure:"token" toml:"token"`
+}
+
+// Validate checks the enabled operations are protected by a token long
+// enough
+func (cfg OperationsConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if len(cfg.Token) < minOperationsTokenLength {
+		return fmt.Errorf("the admin operations are enabled, but the token has less than %d characters", minOperationsTokenLength)
+	}
+	return nil
+}
+
+// operationsAuthentication middleware passes only the requests with the
+// token of the admin operations
+func operationsAuthentication(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := r.Header.Get(OperationsTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			err := server.SendUnauthorized(w, "missing or invalid admin operations token")
+			if err != nil {
+				log.Error().Err(err).Msg(merrors.ResponseDataError)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
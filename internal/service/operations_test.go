@@ -0,0 +1,90 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestOperationsConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        service.OperationsConfig
+		expectedError bool
+	}{
+		{"disabled", service.OperationsConfig{}, false},
+		{"enabled", service.OperationsConfig{Enabled: true, Token: operationsToken}, false},
+		{"missing token", service.OperationsConfig{Enabled: true}, true},
+		{"short token", service.OperationsConfig{Enabled: true, Token: "secret"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestRegisterOperations checks the operations are registered apart from the
+// read-only admin endpoints and need the token
+func TestRegisterOperations(t *testing.T) {
+	store := mockStorage{}
+	handler := service.NewHandler(service.New(service.NewRepository(&store)))
+
+	adminRouter := mux.NewRouter()
+	handler.RegisterAdmin(adminRouter)
+
+	router := mux.NewRouter()
+	handler.RegisterAdmin(router)
+	handler.RegisterOperations(router, operationsToken)
+
+	testCases := []struct {
+		name           string
+		router         *mux.Router
+		token          string
+		expectedStatus int
+	}{
+		{"read-only admin endpoints", adminRouter, operationsToken, http.StatusNotFound},
+		{"missing token", router, "", http.StatusUnauthorized},
+		{"invalid token", router, "fedcba9876543210fedcba9876543210", http.StatusUnauthorized},
+		{"valid token", router, operationsToken, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, service.ReleasesPath+"/"+service.ReleaseClearCanary, http.NoBody)
+			if tc.token != "" {
+				req.Header.Set(service.OperationsTokenHeader, tc.token)
+			}
+			rr := httptest.NewRecorder()
+			tc.router.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	operatorProduct = "insights-operator"
+	clusterProduct  = "cluster"
+)
+
+// versionCoreRegexp matches the major, minor and patch numbers of versions
+// that are not valid semvers, like 4.14.27-$Format:%H$ of development builds
+var versionCoreRegexp = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)`)
+
+// UserAgent structure represents the User-Agent header sent by the Insights
+// Operator, like
+//
+//	insights-operator/4.16.3-202406211206.p0 (linux/amd64) cluster/<cluster ID>
+type UserAgent struct {
+	// OperatorVersion is empty when the client is not the operator
+	OperatorVersion string
+	ClusterID       string
+	// Platform is the comment in parentheses, like linux/amd64
+	Platform string
+}
+
+// ParseUserAgent parses the products and the comment of the User-Agent
+// header. Unknown products are ignored.
+func ParseUserAgent(userAgent string) UserAgent {
+	var ua UserAgent
+
+	if start := strings.Index(userAgent, "("); start >= 0 {
+		if length := strings.Index(userAgent[start:], ")"); length >= 0 {
+			ua.Platform = strings.TrimSpace(userAgent[start+1 : start+length])
+			userAgent = userAgent[:start] + " " + userAgent[start+length+1:]
+		}
+	}
+
+	tokens := strings.FieldsFunc(userAgent, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, token := range tokens {
+		product, version, found := strings.Cut(token, "/")
+		if !found {
+			continue
+		}
+		switch {
+		case product == operatorProduct && ua.OperatorVersion == "":
+			ua.OperatorVersion = version
+		case product == clusterProduct && ua.ClusterID == "":
+			ua.ClusterID = version
+		}
+	}
+	return ua
+}
+
+// Version returns the major, minor and patch numbers of the operator version.
+// The suffixes of the release builds, like 202406211206.p0, are not
+// pre-releases, so they are ignored. It returns nil when the version is
+// unknown.
+func (ua UserAgent) Version() *semver.Version {
+	if ua.OperatorVersion == "" {
+		return nil
+	}
+	if version, err := semver.ParseTolerant(ua.OperatorVersion); err == nil {
+		version = versionCore(version)
+		return &version
+	}
+	if core := versionCoreRegexp.FindStringSubmatch(ua.OperatorVersion); core != nil {
+		version := semver.MustParse(core[1])
+		return &version
+	}
+	return nil
+}
+
+// versionCore returns the major, minor and patch numbers of the version
+func versionCore(version semver.Version) semver.Version {
+	return semver.Version{Major: version.Major, Minor: version.Minor, Patch: version.Patch}
+}
+
+// OperatorVersion is a bound of an operator version range. The version is
+// parsed once when the bound is decoded, the invalid version is reported by
+// the validation of the range.
+type OperatorVersion struct {
+	raw     string
+	version semver.Version
+	err     error
+}
+
+// NewOperatorVersion parses the bound of an operator version range
+func NewOperatorVersion(raw string) *OperatorVersion {
+	bound := &OperatorVersion{raw: raw}
+	bound.version, bound.err = semver.Parse(raw)
+	return bound
+}
+
+// String returns the bound as it was decoded
+func (v OperatorVersion) String() string {
+	return v.raw
+}
+
+// UnmarshalJSON parses the bound from the JSON string
+func (v *OperatorVersion) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*v = *NewOperatorVersion(raw)
+	return nil
+}
+
+// MarshalJSON returns the bound as the JSON string
+func (v OperatorVersion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.raw)
+}
+
+// OperatorVersionRange structure restricts a rule or a remote configuration
+// to the operator versions. Both bounds are optional and inclusive, only
+// their major, minor and patch numbers are compared.
+type OperatorVersionRange struct {
+	MinOperatorVersion *OperatorVersion `json:"min_operator_version,omitempty"`
+	MaxOperatorVersion *OperatorVersion `json:"max_operator_version,omitempty"`
+}
+
+// Validate checks the bounds are valid semvers
+func (r OperatorVersionRange) Validate() error {
+	if r.MinOperatorVersion != nil && r.MinOperatorVersion.err != nil {
+		return fmt.Errorf("min_operator_version: %w", r.MinOperatorVersion.err)
+	}
+	if r.MaxOperatorVersion != nil && r.MaxOperatorVersion.err != nil {
+		return fmt.Errorf("max_operator_version: %w", r.MaxOperatorVersion.err)
+	}
+	return nil
+}
+
+// Contains returns true when the version is inside of the range. The range
+// with invalid bounds contains no version.
+func (r OperatorVersionRange) Contains(version semver.Version) bool {
+	if err := r.Validate(); err != nil {
+		log.Warn().Err(err).Msg("Invalid operator version range")
+		return false
+	}
+	version = versionCore(version)
+	if r.MinOperatorVersion != nil && version.LT(versionCore(r.MinOperatorVersion.version)) {
+		return false
+	}
+	if r.MaxOperatorVersion != nil && version.GT(versionCore(r.MaxOperatorVersion.version)) {
+		return false
+	}
+	return true
+}
+
+// Compatibility structure restricts a remote configuration file to the
+// operator versions. The operators out of the range are served the fallback
+// file, if any, which is relative to the directory of the file.
+type Compatibility struct {
+	OperatorVersionRange
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// fallbackFilepath returns the path of the fallback of the file
+func fallbackFilepath(file, fallback string) (string, error) {
+	if !filepath.IsLocal(fallback) {
+		return "", fmt.Errorf("fallback '%s' is not local", fallback)
+	}
+	return filepath.Join(filepath.Dir(file), fallback), nil
+}
+
+// compatibleRules returns the rules supported by the operator without their
+// version ranges. All the rules are supported when the version is unknown.
+func compatibleRules(rules []Rule, version *semver.Version) []Rule {
+	if rules == nil {
+		return nil
+	}
+
+	compatible := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if version != nil && !rule.OperatorVersionRange.Contains(*version) {
+			continue
+		}
+		rule.OperatorVersionRange = OperatorVersionRange{}
+		compatible = append(compatible, rule)
+	}
+	return compatible
+}
@@ -0,0 +1,191 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	testCases := []struct {
+		name            string
+		userAgent       string
+		expected        service.UserAgent
+		expectedVersion string
+	}{
+		{
+			name:            "development build",
+			userAgent:       canaryUserAgent,
+			expected:        service.UserAgent{OperatorVersion: "4.14.27-$Format:%H$", ClusterID: canaryClusterID},
+			expectedVersion: "4.14.27",
+		},
+		{
+			name:      "release build with platform",
+			userAgent: "insights-operator/v4.16.3-202406211206.p0.g1a2b3c4.assembly.stream.el9 (linux/amd64) cluster/" + canaryClusterID,
+			expected: service.UserAgent{
+				OperatorVersion: "v4.16.3-202406211206.p0.g1a2b3c4.assembly.stream.el9",
+				ClusterID:       canaryClusterID,
+				Platform:        "linux/amd64",
+			},
+			expectedVersion: "4.16.3",
+		},
+		{
+			name:      "extra products",
+			userAgent: "insights-operator/4.17.0 cluster/" + canaryClusterID + ", Go-http-client/1.1",
+			expected: service.UserAgent{
+				OperatorVersion: "4.17.0",
+				ClusterID:       canaryClusterID,
+			},
+			expectedVersion: "4.17.0",
+		},
+		{
+			name:      "other client",
+			userAgent: "Go-http-client/1.1",
+			expected:  service.UserAgent{},
+		},
+		{
+			name:      "invalid operator version",
+			userAgent: "insights-operator/latest cluster/" + canaryClusterID,
+			expected:  service.UserAgent{OperatorVersion: "latest", ClusterID: canaryClusterID},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userAgent := service.ParseUserAgent(tc.userAgent)
+			assert.Equal(t, tc.expected, userAgent)
+
+			version := userAgent.Version()
+			if tc.expectedVersion == "" {
+				assert.Nil(t, version)
+			} else {
+				require.NotNil(t, version)
+				assert.Equal(t, tc.expectedVersion, version.String())
+			}
+		})
+	}
+}
+
+func TestOperatorVersionRangeContains(t *testing.T) {
+	testCases := []struct {
+		name            string
+		r               service.OperatorVersionRange
+		operatorVersion string
+		expected        bool
+	}{
+		{"no bounds", service.OperatorVersionRange{}, "4.16.0", true},
+		{"minimum is inclusive", service.OperatorVersionRange{MinOperatorVersion: service.NewOperatorVersion("4.16.0")}, "4.16.0", true},
+		{"below minimum", service.OperatorVersionRange{MinOperatorVersion: service.NewOperatorVersion("4.16.0")}, "4.15.9", false},
+		{
+			name:            "release build at minimum",
+			r:               service.OperatorVersionRange{MinOperatorVersion: service.NewOperatorVersion("4.16.3")},
+			operatorVersion: "4.16.3-202406211206.p0.g1a2b3c4.assembly.stream.el9",
+			expected:        true,
+		},
+		{
+			name:            "release build at maximum",
+			r:               service.OperatorVersionRange{MaxOperatorVersion: service.NewOperatorVersion("4.16.3")},
+			operatorVersion: "v4.16.3-202406211206.p0.g1a2b3c4.assembly.stream.el9",
+			expected:        true,
+		},
+		{"maximum is inclusive", service.OperatorVersionRange{MaxOperatorVersion: service.NewOperatorVersion("4.17.0")}, "4.17.0", true},
+		{"above maximum", service.OperatorVersionRange{MaxOperatorVersion: service.NewOperatorVersion("4.17.0")}, "4.17.1", false},
+		{"invalid bound", service.OperatorVersionRange{MinOperatorVersion: service.NewOperatorVersion("4.x")}, "4.16.0", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			version := service.ParseUserAgent("insights-operator/" + tc.operatorVersion).Version()
+			require.NotNil(t, version)
+			assert.Equal(t, tc.expected, tc.r.Contains(*version))
+		})
+	}
+}
+
+// TestOperatorVersionRangeJSON checks the bounds are parsed when they're
+// decoded and encoded as they were read
+func TestOperatorVersionRangeJSON(t *testing.T) {
+	var r service.OperatorVersionRange
+	require.NoError(t, json.Unmarshal([]byte(`{"min_operator_version":"4.16.0","max_operator_version":"4.x"}`), &r))
+	assert.Equal(t, "4.16.0", r.MinOperatorVersion.String())
+	assert.ErrorContains(t, r.Validate(), "max_operator_version")
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"min_operator_version":"4.16.0","max_operator_version":"4.x"}`, string(data))
+
+	data, err = json.Marshal(service.OperatorVersionRange{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+}
+
+func TestRepositoryOperatorVersions(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: "testdata/v2_operator_versions",
+	}, false, nil)
+	require.NoError(t, err)
+	repo := service.NewRepository(storage)
+
+	testCases := []struct {
+		name            string
+		userAgent       string
+		expectedVersion string
+		expectedRules   int
+	}{
+		{"unknown operator version", "Go-http-client/1.1", "2.0.0", 2},
+		{"supported operator", "insights-operator/4.16.3 cluster/" + canaryClusterID, "2.0.0", 2},
+		{"rule is not supported", "insights-operator/4.18.0 cluster/" + canaryClusterID, "2.0.0", 1},
+		{"fallback is served", "insights-operator/4.15.2 cluster/" + canaryClusterID, "1.0.0", 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/v2/4.16.0/gathering_rules", http.NoBody)
+			require.NoError(t, err)
+			req.Header.Set("User-Agent", tc.userAgent)
+
+			remoteConfig, err := repo.RemoteConfiguration(req, "4.16.0")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedVersion, remoteConfig.Version)
+			assert.Len(t, remoteConfig.ConditionalRules, tc.expectedRules)
+			// the compatibility metadata is not served
+			assert.Equal(t, service.Compatibility{}, remoteConfig.Compatibility)
+			for _, rule := range remoteConfig.ConditionalRules {
+				assert.Equal(t, service.OperatorVersionRange{}, rule.OperatorVersionRange)
+			}
+		})
+	}
+}
+
+func TestStorageInvalidFallbacks(t *testing.T) {
+	_, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: "testdata/v2_fallback_cycle",
+	}, false, nil)
+
+	var invalidErr *merrors.InvalidEntryError
+	assert.ErrorAs(t, err, &invalidErr)
+	assert.ErrorContains(t, err, "conditional_gathering_rules[0] of 'testdata/v2_fallback_cycle/stable/first.json' is invalid: min_operator_version")
+	assert.ErrorContains(t, err, "store data for 'testdata/v2_fallback_cycle/stable/first.json' are corrupt: the fallbacks make a cycle")
+}
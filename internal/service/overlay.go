@@ -0,0 +1,181 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// Kinds of the overlays, they are stored in the subdirectories of the same
+// names
+const (
+	OverlayChannel = "channels"
+	OverlayOrg     = "orgs"
+	OverlayCluster = "clusters"
+)
+
+// overlayKinds are ordered by the application of the overlays, the more
+// specific overlays are applied later
+var overlayKinds = []string{OverlayChannel, OverlayOrg, OverlayCluster}
+
+// overlay keys are used in the file names, so they are restricted
+var overlayKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Overlay structure represents a partial remote configuration merged on top
+// of the remote configuration selected by the cluster version:
+//
+//   - the conditional gathering rules are appended, a rule with the same
+//     ID as an existing one replaces it, like in the included files
+//   - the container logs requests are appended, a request for the same
+//     namespace and pod name regex as an existing one replaces it
+//
+// The version of the remote configuration is not changed.
+type Overlay struct {
+	// Name is the kind and key of the overlay, like clusters/<cluster ID>
+	Name                  string                `json:"-"`
+	ConditionalRules      []Rule                `json:"conditional_gathering_rules"`
+	ContainerLogsRequests []ContainerLogRequest `json:"container_logs"`
+}
+
+// OverlayKeys structure identifies the overlays applicable to a request
+type OverlayKeys struct {
+	Channel   string
+	OrgID     string
+	ClusterID string
+}
+
+// key returns the key of the overlays of given kind
+func (k OverlayKeys) key(kind string) string {
+	switch kind {
+	case OverlayChannel:
+		return k.Channel
+	case OverlayOrg:
+		return k.OrgID
+	default:
+		return k.ClusterID
+	}
+}
+
+// Apply merges the overlay into the remote configuration
+func (o *Overlay) Apply(config *RemoteConfiguration) {
+	for _, rule := range o.ConditionalRules {
+		id := rule.RuleID()
+		i := slices.IndexFunc(config.ConditionalRules, func(r Rule) bool { return r.RuleID() == id })
+		if i >= 0 {
+			config.ConditionalRules[i] = rule
+		} else {
+			config.ConditionalRules = append(config.ConditionalRules, rule)
+		}
+	}
+
+	for _, request := range o.ContainerLogsRequests {
+		i := slices.IndexFunc(config.ContainerLogsRequests, func(r ContainerLogRequest) bool {
+			return r.Namespace == request.Namespace && r.PodNameRegex == request.PodNameRegex
+		})
+		if i >= 0 {
+			config.ContainerLogsRequests[i] = request
+		} else {
+			config.ContainerLogsRequests = append(config.ContainerLogsRequests, request)
+		}
+	}
+}
+
+// loadOverlays reads all the overlays from the directory. The overlays are
+// stored as <kind>/<key>.json, missing kind directories are skipped.
+func loadOverlays(dir string) (map[string]*Overlay, error) {
+	overlays := map[string]*Overlay{}
+
+	for _, kind := range overlayKinds {
+		kindDir := filepath.Join(dir, kind)
+		entries, err := os.ReadDir(kindDir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, &merrors.StorageIOError{Path: kindDir, Err: err}
+		}
+
+		for _, entry := range entries {
+			key, isJSON := strings.CutSuffix(entry.Name(), ".json")
+			if entry.IsDir() || !isJSON {
+				continue
+			}
+			path := filepath.Join(kindDir, entry.Name())
+			if err := validateOverlayKey(kind, key); err != nil {
+				return nil, fmt.Errorf("invalid overlay '%s': %w", path, err)
+			}
+
+			data, err := os.ReadFile(path) // #nosec G304 -- path is constructed from the configured directory
+			if err != nil {
+				return nil, &merrors.StorageIOError{Path: path, Err: err}
+			}
+			overlay := Overlay{Name: kind + "/" + key}
+			if err := json.Unmarshal(data, &overlay); err != nil {
+				return nil, &merrors.CorruptDataError{Path: path, Err: err}
+			}
+			err = errors.Join(
+				validateRules(path, overlay.ConditionalRules),
+				validateContainerLogRequests(path, overlay.ContainerLogsRequests),
+			)
+			if err != nil {
+				return nil, err
+			}
+			overlays[overlay.Name] = &overlay
+		}
+	}
+
+	log.Info().Int("count", len(overlays)).Str("path", dir).Msg("Overlays loaded")
+	return overlays, nil
+}
+
+func validateOverlayKey(kind, key string) error {
+	if kind == OverlayChannel && key != StableVersion && key != CanaryVersion {
+		return fmt.Errorf("channel must be '%s' or '%s'", StableVersion, CanaryVersion)
+	}
+	if !overlayKeyRegexp.MatchString(key) {
+		return fmt.Errorf("name must match %s", overlayKeyRegexp)
+	}
+	return nil
+}
+
+// selectOverlays returns the overlays for the keys in the order of their
+// application
+func selectOverlays(overlays map[string]*Overlay, keys OverlayKeys) []*Overlay {
+	var selected []*Overlay
+	for _, kind := range overlayKinds {
+		key := keys.key(kind)
+		if !overlayKeyRegexp.MatchString(key) {
+			continue
+		}
+		if overlay, found := overlays[kind+"/"+key]; found {
+			selected = append(selected, overlay)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+const overlaysFolder = "testdata/overlays"
+
+func alertRule(name string, tailLines int) service.Rule {
+	return service.Rule{
+		Conditions: []interface{}{alertCondition(name)},
+		GatheringFunctions: map[string]interface{}{
+			"containers_logs": map[string]interface{}{"alert_name": name, "tail_lines": tailLines},
+		},
+	}
+}
+
+func withID(rule service.Rule, id string) service.Rule {
+	rule.ID = id
+	return rule
+}
+
+func TestOverlayApply(t *testing.T) {
+	base := func() service.RemoteConfiguration {
+		return service.RemoteConfiguration{
+			Version:          "1.1.0",
+			ConditionalRules: []service.Rule{alertRule("A", 10), withID(alertRule("B", 10), "rule-b")},
+			ContainerLogsRequests: []service.ContainerLogRequest{
+				{Namespace: "ns", PodNameRegex: "pod-.*", Messages: []string{"base"}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		overlay  service.Overlay
+		expected service.RemoteConfiguration
+	}{
+		{
+			name:     "empty overlay",
+			overlay:  service.Overlay{},
+			expected: base(),
+		},
+		{
+			name:    "rule with the same ID is replaced",
+			overlay: service.Overlay{ConditionalRules: []service.Rule{withID(alertRule("B", 50), "rule-b")}},
+			expected: service.RemoteConfiguration{
+				Version:               "1.1.0",
+				ConditionalRules:      []service.Rule{alertRule("A", 10), withID(alertRule("B", 50), "rule-b")},
+				ContainerLogsRequests: base().ContainerLogsRequests,
+			},
+		},
+		{
+			name:     "rule with the same content is replaced",
+			overlay:  service.Overlay{ConditionalRules: []service.Rule{alertRule("A", 10)}},
+			expected: base(),
+		},
+		{
+			name:    "rule with the same conditions and another ID is appended",
+			overlay: service.Overlay{ConditionalRules: []service.Rule{withID(alertRule("B", 10), "rule-c")}},
+			expected: service.RemoteConfiguration{
+				Version: "1.1.0",
+				ConditionalRules: []service.Rule{
+					alertRule("A", 10), withID(alertRule("B", 10), "rule-b"), withID(alertRule("B", 10), "rule-c"),
+				},
+				ContainerLogsRequests: base().ContainerLogsRequests,
+			},
+		},
+		{
+			name:    "new rule is appended",
+			overlay: service.Overlay{ConditionalRules: []service.Rule{alertRule("C", 10)}},
+			expected: service.RemoteConfiguration{
+				Version:               "1.1.0",
+				ConditionalRules:      []service.Rule{alertRule("A", 10), withID(alertRule("B", 10), "rule-b"), alertRule("C", 10)},
+				ContainerLogsRequests: base().ContainerLogsRequests,
+			},
+		},
+		{
+			name: "container logs requests are replaced and appended",
+			overlay: service.Overlay{ContainerLogsRequests: []service.ContainerLogRequest{
+				{Namespace: "ns", PodNameRegex: "pod-.*", Previous: true, Messages: []string{"overlay"}},
+				{Namespace: "other-ns", PodNameRegex: "pod-.*", Messages: []string{"overlay"}},
+			}},
+			expected: service.RemoteConfiguration{
+				Version:          "1.1.0",
+				ConditionalRules: base().ConditionalRules,
+				ContainerLogsRequests: []service.ContainerLogRequest{
+					{Namespace: "ns", PodNameRegex: "pod-.*", Previous: true, Messages: []string{"overlay"}},
+					{Namespace: "other-ns", PodNameRegex: "pod-.*", Messages: []string{"overlay"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := base()
+			tc.overlay.Apply(&config)
+			assert.Equal(t, tc.expected, config)
+		})
+	}
+}
+
+func TestStorageOverlays(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: v2Folder,
+		OverlaysPath:             overlaysFolder,
+	}, false, nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		keys          service.OverlayKeys
+		expectedNames []string
+	}{
+		{"no overlay", service.OverlayKeys{Channel: service.StableVersion, ClusterID: "unknown"}, nil},
+		{
+			"all overlays",
+			service.OverlayKeys{Channel: service.CanaryVersion, OrgID: "123456", ClusterID: canaryClusterID},
+			[]string{"channels/canary", "orgs/123456", "clusters/" + canaryClusterID},
+		},
+		{"invalid cluster ID", service.OverlayKeys{ClusterID: "../orgs/123456"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var names []string
+			for _, overlay := range storage.Overlays(tc.keys) {
+				names = append(names, overlay.Name)
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestStorageInvalidOverlays(t *testing.T) {
+	_, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: v2Folder,
+		OverlaysPath:             "testdata/overlays_invalid",
+	}, false, nil)
+	assert.ErrorContains(t, err, "beta.json")
+
+	_, err = service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: v2Folder,
+		OverlaysPath:             "testdata/overlays_corrupt",
+	}, false, nil)
+	var corruptErr *merrors.CorruptDataError
+	assert.ErrorAs(t, err, &corruptErr)
+}
+
+// TestRepositoryAppliesOverlays checks that the overlays of the cluster and
+// its organization are merged in order
+func TestRepositoryAppliesOverlays(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: v2Folder,
+		OverlaysPath:             overlaysFolder,
+	}, false, nil)
+	require.NoError(t, err)
+	repo := service.NewRepository(storage)
+
+	req, err := http.NewRequest(http.MethodGet, "/v2/4.17.0/gathering_rules", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", canaryUserAgent)
+	identity := server.Identity{Internal: server.Internal{OrgID: 123456}}
+	req = req.WithContext(context.WithValue(req.Context(), server.ContextKeyUser, identity))
+
+	remoteConfig, err := repo.RemoteConfiguration(req, "4.17.0")
+	require.NoError(t, err)
+	assert.Equal(t, validStableRemoteConfiguration.ConditionalRules, remoteConfig.ConditionalRules)
+
+	logs, err := json.Marshal(remoteConfig.ContainerLogsRequests)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"namespace":"namespace-1","pod_name_regex":"test regex","previous":true,"messages":["first message","second message"]},
+		{"namespace":"openshift-monitoring","pod_name_regex":"prometheus-k8s-.*","previous":true,"messages":["cluster specific message"]}
+	]`, string(logs))
+}
@@ -0,0 +1,242 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/files"
+)
+
+// Operations changing the release state
+const (
+	// ReleasePromote serves the canary snapshot to the stable clusters
+	ReleasePromote = "promote"
+	// ReleaseRollback serves the previous stable snapshot to the stable
+	// clusters
+	ReleaseRollback = "rollback"
+	// ReleaseClearCanary serves the stable snapshot to the canary clusters
+	ReleaseClearCanary = "clear_canary"
+)
+
+// ReleaseState structure represents the snapshots served to the clusters of
+// the channels. The snapshots are the stable and canary configurations
+// loaded on startup.
+type ReleaseState struct {
+	Stable string `json:"stable"`
+	// Canary is empty when the canary clusters get the stable snapshot
+	Canary string `json:"canary"`
+	// History holds the previous stable snapshots, the last one is restored
+	// by the rollback
+	History []string `json:"history"`
+	// Snapshots holds the IDs of the loaded snapshots. The state is reset
+	// when they change, like after a new deployment.
+	Snapshots map[string]string `json:"snapshots"`
+}
+
+// initialReleaseState returns the state serving the snapshots to their own
+// channels
+func initialReleaseState(snapshots map[string]string) ReleaseState {
+	return ReleaseState{
+		Stable:    StableVersion,
+		Canary:    CanaryVersion,
+		History:   []string{},
+		Snapshots: snapshots,
+	}
+}
+
+// Releases holds the release state. The state is written to a file, if
+// configured, so it's kept after a restart of the service.
+type Releases struct {
+	path  string
+	mutex sync.RWMutex
+	state ReleaseState
+}
+
+// NewReleases constructs new releases reading the state from the file. The
+// state is kept only in memory when the path is empty. A missing file or a
+// state of other snapshots means the initial state.
+func NewReleases(path string, snapshots map[string]string) (*Releases, error) {
+	r := &Releases{path: path}
+	state := initialReleaseState(snapshots)
+	if path == "" {
+		r.setState(state)
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, &merrors.StorageIOError{Path: path, Err: err}
+	default:
+		var stored ReleaseState
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return nil, &merrors.CorruptDataError{Path: path, Err: err}
+		}
+		if maps.Equal(stored.Snapshots, snapshots) {
+			state = stored
+		} else {
+			log.Info().
+				Interface("stored", stored.Snapshots).
+				Interface("loaded", snapshots).
+				Msg("The snapshots have changed, the release state is reset")
+		}
+	}
+
+	if state.History == nil {
+		state.History = []string{}
+	}
+	r.setState(state)
+	return r, nil
+}
+
+// State returns the current release state
+func (r *Releases) State() ReleaseState {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	state := r.state
+	state.History = slices.Clone(state.History)
+	return state
+}
+
+// ServesCanary returns true when the clusters of the channel get the canary
+// snapshot
+func (r *Releases) ServesCanary(isCanary bool) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	snapshot := r.state.Stable
+	if isCanary && r.state.Canary != "" {
+		snapshot = r.state.Canary
+	}
+	return snapshot == CanaryVersion
+}
+
+// Apply changes the release state by the operation and writes it to the file
+func (r *Releases) Apply(operation string) (ReleaseState, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	state := r.state
+	state.History = slices.Clone(state.History)
+	switch operation {
+	case ReleasePromote:
+		if state.Canary == "" || state.Canary == state.Stable {
+			return r.state, &merrors.ConflictError{ErrString: "there is no canary snapshot to promote"}
+		}
+		state.History = append(state.History, state.Stable)
+		state.Stable = state.Canary
+		state.Canary = ""
+	case ReleaseRollback:
+		if len(state.History) == 0 {
+			return r.state, &merrors.ConflictError{ErrString: "there is no previous stable snapshot"}
+		}
+		state.Stable = state.History[len(state.History)-1]
+		state.History = state.History[:len(state.History)-1]
+	case ReleaseClearCanary:
+		state.Canary = ""
+	default:
+		return r.state, &merrors.ValidationError{
+			ParamName:  "operation",
+			ParamValue: operation,
+			ErrString:  "unknown release operation"}
+	}
+
+	if r.path != "" {
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return r.state, err
+		}
+		if err := files.WriteAtomic(r.path, data, 0o600); err != nil {
+			return r.state, err
+		}
+	}
+
+	r.state = state
+	r.updateMetric()
+	log.Info().Str("operation", operation).Interface("state", state).Msg("Release state updated")
+	return state, nil
+}
+
+// setState replaces the state and updates the metric of the served
+// snapshots
+func (r *Releases) setState(state ReleaseState) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.state = state
+	r.updateMetric()
+}
+
+// updateMetric sets the snapshots served to the channels, the mutex must be
+// held
+func (r *Releases) updateMetric() {
+	canary := r.state.Canary
+	if canary == "" {
+		canary = r.state.Stable
+	}
+	releaseSnapshotMetric.Reset()
+	releaseSnapshotMetric.WithLabelValues(StableVersion, r.state.Stable).Set(1)
+	releaseSnapshotMetric.WithLabelValues(CanaryVersion, canary).Set(1)
+}
+
+// SnapshotIDs returns the IDs of the stable and canary snapshots loaded on
+// startup. An ID is the hash of the cluster mapping, the remote
+// configurations and the rules of the channel.
+func (s *Storage) SnapshotIDs() map[string]string {
+	return map[string]string{
+		StableVersion: s.snapshotID(StableVersion, s.stableClusterMapping),
+		CanaryVersion: s.snapshotID(CanaryVersion, s.canaryClusterMapping),
+	}
+}
+
+func (s *Storage) snapshotID(channel string, cm *ClusterMapping) string {
+	hash := sha256.New()
+	if cm != nil {
+		mapping, _ := json.Marshal(cm.mapping)
+		hash.Write(mapping)
+	}
+
+	channelDir := filepath.Join(s.remoteConfigurationsPath, channel) + string(filepath.Separator)
+	for _, path := range s.remoteConfigurationFilepaths {
+		if strings.HasPrefix(path, channelDir) {
+			hash.Write([]byte(strings.TrimPrefix(path, channelDir)))
+			hash.Write(s.cache.Get(path))
+		}
+	}
+
+	if s.conditionalRulesPath != "" {
+		rules, err := s.ReadConditionalRules(context.Background(), channel == CanaryVersion, "rules.json")
+		if err == nil {
+			hash.Write(rules)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:contentIDLength]
+}
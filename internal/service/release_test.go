@@ -0,0 +1,177 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestReleasesApply(t *testing.T) {
+	releases, err := service.NewReleases("", nil)
+	require.NoError(t, err)
+	assert.False(t, releases.ServesCanary(false))
+	assert.True(t, releases.ServesCanary(true))
+
+	var conflictErr *merrors.ConflictError
+	_, err = releases.Apply(service.ReleaseRollback)
+	assert.ErrorAs(t, err, &conflictErr)
+
+	state, err := releases.Apply(service.ReleasePromote)
+	require.NoError(t, err)
+	assert.Equal(t, service.ReleaseState{
+		Stable:  service.CanaryVersion,
+		Canary:  "",
+		History: []string{service.StableVersion},
+	}, state)
+	assert.True(t, releases.ServesCanary(false))
+	assert.True(t, releases.ServesCanary(true))
+	assert.Equal(t, 1.0, testutil.ToFloat64(service.ReleaseSnapshotMetric.WithLabelValues(service.StableVersion, service.CanaryVersion)))
+	assert.Equal(t, 2, testutil.CollectAndCount(service.ReleaseSnapshotMetric))
+
+	_, err = releases.Apply(service.ReleasePromote)
+	assert.ErrorAs(t, err, &conflictErr)
+
+	// the canary snapshot stays cleared after the rollback
+	state, err = releases.Apply(service.ReleaseRollback)
+	require.NoError(t, err)
+	assert.Equal(t, service.StableVersion, state.Stable)
+	assert.Empty(t, state.History)
+	assert.False(t, releases.ServesCanary(false))
+	assert.False(t, releases.ServesCanary(true))
+
+	_, err = releases.Apply(service.ReleaseClearCanary)
+	assert.NoError(t, err)
+
+	var validationErr *merrors.ValidationError
+	_, err = releases.Apply("deploy")
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+// TestReleasesFile checks the state is kept in the file until the snapshots
+// change
+func TestReleasesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release_state.json")
+	snapshots := map[string]string{service.StableVersion: "aaa", service.CanaryVersion: "bbb"}
+
+	releases, err := service.NewReleases(path, snapshots)
+	require.NoError(t, err)
+	_, err = releases.Apply(service.ReleasePromote)
+	require.NoError(t, err)
+
+	releases, err = service.NewReleases(path, snapshots)
+	require.NoError(t, err)
+	assert.Equal(t, service.CanaryVersion, releases.State().Stable)
+
+	releases, err = service.NewReleases(path, map[string]string{service.StableVersion: "bbb", service.CanaryVersion: "ccc"})
+	require.NoError(t, err)
+	assert.Equal(t, service.StableVersion, releases.State().Stable)
+	assert.Equal(t, service.CanaryVersion, releases.State().Canary)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"stable":`), 0o600))
+	_, err = service.NewReleases(path, snapshots)
+	var corruptErr *merrors.CorruptDataError
+	assert.ErrorAs(t, err, &corruptErr)
+}
+
+func TestStorageSnapshotIDs(t *testing.T) {
+	load := func() map[string]string {
+		storage, err := service.NewStorage(service.StorageConfig{
+			RemoteConfigurationsPath: channelParentFolder,
+		}, false, nil)
+		require.NoError(t, err)
+		return storage.SnapshotIDs()
+	}
+
+	snapshots := load()
+	assert.Len(t, snapshots, 2)
+	assert.NotEqual(t, snapshots[service.StableVersion], snapshots[service.CanaryVersion])
+	assert.Equal(t, snapshots, load())
+}
+
+func TestReleaseEndpoints(t *testing.T) {
+	storage, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: channelParentFolder,
+	}, true, &MockUnleashClient{})
+	require.NoError(t, err)
+	releases, err := service.NewReleases("", storage.SnapshotIDs())
+	require.NoError(t, err)
+	repo := service.NewRepository(storage)
+	repo.SetReleases(releases)
+	handler := service.NewHandler(service.New(repo))
+	router := mux.NewRouter()
+	handler.Register(router)
+	handler.RegisterAdmin(router)
+	handler.RegisterOperations(router, operationsToken)
+
+	servedVersion := func(t *testing.T) string {
+		req := httptest.NewRequest(http.MethodGet, service.APIPrefix+service.V2Prefix+"/4.17.0/gathering_rules", http.NoBody)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var remoteConfig service.RemoteConfiguration
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &remoteConfig))
+		return remoteConfig.Version
+	}
+
+	testCases := []struct {
+		operation       string
+		expectedStatus  int
+		expectedVersion string
+	}{
+		{service.ReleasePromote, http.StatusOK, "1.2.0-canary"},
+		{service.ReleasePromote, http.StatusConflict, "1.2.0-canary"},
+		{service.ReleaseRollback, http.StatusOK, "1.1.0"},
+		{service.ReleaseRollback, http.StatusConflict, "1.1.0"},
+		{service.ReleaseClearCanary, http.StatusOK, "1.1.0"},
+		{"deploy", http.StatusBadRequest, "1.1.0"},
+	}
+
+	assert.Equal(t, "1.1.0", servedVersion(t))
+	for _, tc := range testCases {
+		t.Run(tc.operation, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, service.ReleasesPath+"/"+tc.operation, http.NoBody)
+			req.Header.Set(service.OperationsTokenHeader, operationsToken)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedVersion, servedVersion(t))
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, service.ReleasesPath, http.NoBody)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var state service.ReleaseState
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &state))
+	assert.Equal(t, service.StableVersion, state.Stable)
+	assert.Empty(t, state.Canary)
+	assert.Equal(t, storage.SnapshotIDs(), state.Snapshots)
+}
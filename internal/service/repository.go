@@ -17,21 +17,49 @@ limitations under the License.
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/rs/zerolog"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
 )
 
 // RepositoryInterface defines methods to be implemented by any rules providers
 type RepositoryInterface interface {
 	Rules(r *http.Request) (*Rules, error)
 	RemoteConfiguration(r *http.Request, ocpVersion string) (*RemoteConfiguration, error)
+	ResolveRemoteConfiguration(r *http.Request, ocpVersion string) (*RemoteConfiguration, error)
+	ExpiredEntries(ctx context.Context) ([]ExpiredEntry, error)
+	DenyList() DenyList
+	UpdateDenyList(denyList DenyList) error
+	ReleaseState() ReleaseState
+	UpdateRelease(operation string) (ReleaseState, error)
+	ReportFeedback(r *http.Request, feedback *Feedback) error
+	LedgerRecords(ctx context.Context, filter LedgerFilter) ([]LedgerRecord, error)
+	UnmappedVersions(top int) []UnmappedVersion
+	Ready(ctx context.Context) error
+	Close() error
 }
 
 // Rule data type definition based on original JSON schema
 type Rule struct {
+	// ID optionally identifies the rule, like in the deny-list
+	ID                 string        `json:"id,omitempty"`
 	Conditions         []interface{} `json:"conditions,omitempty"`
 	GatheringFunctions interface{}   `json:"gathering_functions,omitempty"`
+	Metadata           *RuleMetadata `json:"metadata,omitempty"`
+	Activation
+	OperatorVersionRange
 }
 
 // Rules data type definition based on original JSON schema
@@ -47,6 +75,7 @@ type ContainerLogRequest struct {
 	PodNameRegex string   `json:"pod_name_regex"`
 	Previous     bool     `json:"previous,omitempty"`
 	Messages     []string `json:"messages"`
+	Activation
 }
 
 // RemoteConfiguration represents the new data structure
@@ -55,55 +84,510 @@ type RemoteConfiguration struct {
 	ConditionalRules      []Rule                `json:"conditional_gathering_rules"`
 	ContainerLogsRequests []ContainerLogRequest `json:"container_logs"`
 	Version               string                `json:"version"`
+	Compatibility
+	// Origin is set by the repository, it's not served
+	Origin *ConfigurationOrigin `json:"-"`
 }
 
 // Repository is definition of objects that implement the RepositoryInterface
 type Repository struct {
-	store StorageInterface
+	store         StorageInterface
+	clock         Clock
+	killSwitch    *KillSwitch
+	releases      *Releases
+	guardrail     *Guardrail
+	ledger        *Ledger
+	stripMetadata bool
+
+	// versions and gathering functions of the loaded remote
+	// configurations, they're collected on the first feedback
+	loadedOnce         sync.Once
+	versions           map[string]bool
+	gatheringFunctions map[string]bool
 }
 
-// NewRepository constructs new instance of Repository
+// NewRepository constructs new instance of Repository. Its deny-list and
+// release state are kept only in memory until a kill switch and releases
+// are set. The guardrail and the ledger are disabled until they're set.
 func NewRepository(s StorageInterface) *Repository {
-	return &Repository{store: s}
+	killSwitch, _ := NewKillSwitch("")
+	releases, _ := NewReleases("", nil)
+	return &Repository{
+		store:      s,
+		clock:      time.Now,
+		killSwitch: killSwitch,
+		releases:   releases,
+		guardrail:  NewGuardrail(GuardrailConfig{}),
+	}
+}
+
+// SetReleases replaces the release state selecting the snapshots served to
+// the channels
+func (r *Repository) SetReleases(releases *Releases) {
+	r.releases = releases
+}
+
+// ReleaseState returns the snapshots served to the channels
+func (r *Repository) ReleaseState() ReleaseState {
+	return r.releases.State()
+}
+
+// UpdateRelease changes the snapshots served to the channels by the
+// operation
+func (r *Repository) UpdateRelease(operation string) (ReleaseState, error) {
+	return r.releases.Apply(operation)
+}
+
+// SetGuardrail replaces the guardrail clearing the canary snapshot
+func (r *Repository) SetGuardrail(guardrail *Guardrail) {
+	r.guardrail = guardrail
+}
+
+// ReportFeedback aggregates the outcome of applying the remote configuration
+// reported by the cluster. The channel of the feedback is the snapshot served
+// to the cluster. The canary snapshot is cleared when the guardrail trips.
+func (r *Repository) ReportFeedback(request *http.Request, feedback *Feedback) error {
+	if err := feedback.Validate(); err != nil {
+		return err
+	}
+	channel := StableVersion
+	if r.releases.ServesCanary(r.store.IsCanary(request)) {
+		channel = CanaryVersion
+	}
+	logRequestDetails(request, channel == CanaryVersion, "", "")
+
+	// the versions and gathering functions unknown to the service are not
+	// used as labels, so the clusters can't grow the metrics
+	versions, gatheringFunctions := r.loadedConfigurations(request.Context())
+	version := feedback.Version
+	if !versions[version] {
+		version = unknownLabel
+	}
+	recordFeedbackMetrics(feedback, channel, version, gatheringFunctions)
+
+	if !r.guardrail.Record(r.clock(), channel, feedback.Failed()) || r.releases.State().Canary == "" {
+		return nil
+	}
+	if _, err := r.releases.Apply(ReleaseClearCanary); err != nil {
+		zerolog.Ctx(request.Context()).Error().Err(err).Msg("The guardrail could not clear the canary snapshot")
+		return nil
+	}
+	guardrailTripsMetric.Inc()
+	zerolog.Ctx(request.Context()).Warn().Msg("The error rate of the canary feedbacks is too high, the canary snapshot was cleared")
+	return nil
+}
+
+// loadedConfigurations returns the versions and the gathering functions of
+// the remote configurations of the cluster mappings
+func (r *Repository) loadedConfigurations(ctx context.Context) (versions, gatheringFunctions map[string]bool) {
+	r.loadedOnce.Do(func() {
+		r.versions = map[string]bool{}
+		r.gatheringFunctions = map[string]bool{}
+		for _, path := range r.store.RemoteConfigurationFilepaths() {
+			data, err := r.store.ReadRemoteConfig(ctx, path)
+			if err != nil {
+				continue
+			}
+			var remoteConfig RemoteConfiguration
+			if json.Unmarshal(data, &remoteConfig) != nil {
+				continue
+			}
+			if remoteConfig.Version != "" {
+				r.versions[remoteConfig.Version] = true
+			}
+			for _, rule := range remoteConfig.ConditionalRules {
+				for name := range fieldsOf(rule.GatheringFunctions) {
+					r.gatheringFunctions[name] = true
+				}
+			}
+		}
+	})
+	return r.versions, r.gatheringFunctions
+}
+
+// SetLedger sets the ledger recording the remote configurations served to
+// the clusters
+func (r *Repository) SetLedger(ledger *Ledger) {
+	r.ledger = ledger
+}
+
+// LedgerRecords returns the remote configurations served to the clusters
+// selected by the filter
+func (r *Repository) LedgerRecords(ctx context.Context, filter LedgerFilter) ([]LedgerRecord, error) {
+	if r.ledger == nil {
+		return nil, &merrors.NotFoundError{ErrString: "the ledger is not enabled"}
+	}
+	return r.ledger.Query(ctx, filter)
+}
+
+// UnmappedVersions returns the most requested OCP versions not covered by
+// the cluster mappings
+func (r *Repository) UnmappedVersions(top int) []UnmappedVersion {
+	versions := r.store.UnmappedVersions(top)
+	if versions == nil {
+		return []UnmappedVersion{}
+	}
+	return versions
+}
+
+// Ready returns an error when no remote configuration is loaded. The ledger
+// is written in the background, so it doesn't affect the readiness.
+func (r *Repository) Ready(_ context.Context) error {
+	if len(r.store.RemoteConfigurationFilepaths()) == 0 {
+		return errors.New("no remote configuration is loaded")
+	}
+	return nil
+}
+
+// Close writes the queued records of the ledger and closes its store
+func (r *Repository) Close() error {
+	if r.ledger == nil {
+		return nil
+	}
+	return r.ledger.Close()
+}
+
+// SetKillSwitch replaces the kill switch with the deny-list of the rules
+func (r *Repository) SetKillSwitch(killSwitch *KillSwitch) {
+	r.killSwitch = killSwitch
+}
+
+// DenyList returns the current deny-list of the rules
+func (r *Repository) DenyList() DenyList {
+	return r.killSwitch.DenyList()
+}
+
+// UpdateDenyList replaces the deny-list of the rules
+func (r *Repository) UpdateDenyList(denyList DenyList) error {
+	return r.killSwitch.Update(denyList)
+}
+
+// SetStripMetadata sets whether the metadata of the rules are removed before
+// the rules are served
+func (r *Repository) SetStripMetadata(strip bool) {
+	r.stripMetadata = strip
+}
+
+// SetClock replaces the clock used to decide which rules are active
+func (r *Repository) SetClock(clock Clock) {
+	r.clock = clock
 }
 
 // Rules method reads all and unmarshals all rules stored under given path
 func (r *Repository) Rules(request *http.Request) (*Rules, error) {
 	filepath := "rules.json" // TODO: Make this configurable
-	data := r.store.ReadConditionalRules(r.store.IsCanary(request), filepath)
-	if data == nil {
-		return nil, fmt.Errorf("store data not found for '%s'", filepath)
+	isCanary := r.releases.ServesCanary(r.store.IsCanary(request))
+	logRequestDetails(request, isCanary, "", filepath)
+
+	data, err := r.store.ReadConditionalRules(request.Context(), isCanary, filepath)
+	if err == nil && data == nil {
+		err = &merrors.StorageNotFoundError{Path: filepath}
+	}
+	if err != nil {
+		return nil, countStorageError(err)
 	}
 
 	var rules Rules
-	err := json.Unmarshal(data, &rules)
+	err = json.Unmarshal(data, &rules)
 	if err != nil {
-		return nil, err
+		return nil, countStorageError(&merrors.CorruptDataError{Path: filepath, Err: err})
+	}
+
+	var expired int
+	rules.Items, expired = filterActive(rules.Items, r.clock(), ruleActivation)
+	expiredEntriesMetric.WithLabelValues(rulesSource(isCanary, filepath)).Set(float64(expired))
+	rules.Items = compatibleRules(rules.Items, ParseUserAgent(request.UserAgent()).Version())
+	rules.Items = supportedRules(request, rules.Items, true)
+	rules.Items = r.allowedRules(request, rules.Items)
+	if r.stripMetadata {
+		stripRuleMetadata(rules.Items)
 	}
 
 	return &rules, nil
 }
 
 // RemoteConfiguration returns a remote configuration for v2 endpoint based on
-// the cluster map defined in the settings and loaded on startup
+// the cluster map defined in the settings and loaded on startup. The remote
+// configuration is recorded as served to the cluster.
 func (r *Repository) RemoteConfiguration(request *http.Request, ocpVersion string) (*RemoteConfiguration, error) {
-	isCanary := r.store.IsCanary(request)
-	filepath, err := r.store.GetRemoteConfigurationFilepath(isCanary, ocpVersion)
+	return r.remoteConfiguration(request, ocpVersion, true)
+}
+
+// ResolveRemoteConfiguration returns the remote configuration like
+// RemoteConfiguration without any side effects, like for the evaluation of the
+// conditions. Neither the ledger nor the metrics are updated.
+func (r *Repository) ResolveRemoteConfiguration(request *http.Request, ocpVersion string) (*RemoteConfiguration, error) {
+	return r.remoteConfiguration(request, ocpVersion, false)
+}
+
+// remoteConfiguration selects the remote configuration for the OCP version.
+// Only the served remote configurations update the metrics and the ledger.
+func (r *Repository) remoteConfiguration(request *http.Request, ocpVersion string, served bool) (*RemoteConfiguration, error) {
+	isCanary := r.releases.ServesCanary(r.store.IsCanary(request))
+	filepath, err := r.store.GetRemoteConfigurationFilepath(request.Context(), isCanary, ocpVersion)
+	logRequestDetails(request, isCanary, ocpVersion, filepath)
+	if served {
+		// the versions not covered by the mapping are counted too
+		r.store.RecordOCPVersion(isCanary, ocpVersion)
+	}
 	if err != nil {
 		return nil, err
 	}
-	data := r.store.ReadRemoteConfig(filepath)
-	if data == nil {
-		return nil, fmt.Errorf("store data not found for '%s'", filepath)
-	}
-	var remoteConfig RemoteConfiguration
-	err = json.Unmarshal(data, &remoteConfig)
+	operatorVersion := ParseUserAgent(request.UserAgent()).Version()
+	remoteConfig, filepath, err := r.readCompatibleRemoteConfiguration(request, filepath, operatorVersion)
 	if err != nil {
 		return nil, err
 	}
 
 	// Count the number of times a given remote configuration is returned
-	remoteConfigurationsMetric.WithLabelValues(filepath, remoteConfig.Version).Inc()
+	if served {
+		remoteConfigurationsMetric.WithLabelValues(filepath, remoteConfig.Version).Inc()
+	}
+
+	remoteConfig.Origin = newConfigurationOrigin(isCanary, filepath, r.store.InheritedFrom(filepath))
+	if remoteConfig.Origin.InheritedFrom != "" {
+		zerolog.Ctx(request.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("inheritedFrom", remoteConfig.Origin.InheritedFrom)
+		})
+	}
+
+	now := r.clock()
+	expired := activeRemoteConfiguration(remoteConfig, now)
+	if served {
+		expiredEntriesMetric.WithLabelValues(filepath).Set(float64(expired))
+	}
+
+	r.applyOverlays(request, isCanary, remoteConfig, now, served)
+
+	remoteConfig.ConditionalRules = compatibleRules(remoteConfig.ConditionalRules, operatorVersion)
+	remoteConfig.ConditionalRules = supportedRules(request, remoteConfig.ConditionalRules, served)
+	remoteConfig.ConditionalRules = r.allowedRules(request, remoteConfig.ConditionalRules)
+	if r.stripMetadata {
+		stripRuleMetadata(remoteConfig.ConditionalRules)
+	}
+	remoteConfig.Compatibility = Compatibility{}
+
+	if clusterID := ParseUserAgent(request.UserAgent()).ClusterID; served && r.ledger != nil && clusterID != "" {
+		r.ledger.Record(LedgerRecord{
+			ClusterID:  clusterID,
+			Channel:    remoteConfig.Origin.Channel,
+			OCPVersion: ocpVersion,
+			File:       remoteConfig.Origin.File,
+			Version:    remoteConfig.Version,
+			ServedAt:   now.UTC(),
+		})
+	}
+
+	return remoteConfig, nil
+}
+
+// readCompatibleRemoteConfiguration reads the remote configuration from the
+// file, following its fallbacks until the operator version is supported. It
+// returns the configuration with the file it was read from.
+func (r *Repository) readCompatibleRemoteConfiguration(
+	request *http.Request, file string, version *semver.Version,
+) (*RemoteConfiguration, string, error) {
+	visited := map[string]bool{}
+	for {
+		visited[file] = true
+		data, err := r.store.ReadRemoteConfig(request.Context(), file)
+		if err == nil && data == nil {
+			err = &merrors.StorageNotFoundError{Path: file}
+		}
+		if err != nil {
+			return nil, file, countStorageError(err)
+		}
+		var remoteConfig RemoteConfiguration
+		err = json.Unmarshal(data, &remoteConfig)
+		if err != nil {
+			return nil, file, countStorageError(&merrors.CorruptDataError{Path: file, Err: err})
+		}
+
+		if version == nil || remoteConfig.OperatorVersionRange.Contains(*version) {
+			return &remoteConfig, file, nil
+		}
+		if remoteConfig.Fallback == "" {
+			return nil, file, &merrors.NotFoundError{
+				ErrString: fmt.Sprintf("no remote configuration supports the operator version %s", version)}
+		}
+
+		fallback, err := fallbackFilepath(file, remoteConfig.Fallback)
+		if err == nil && visited[fallback] {
+			err = fmt.Errorf("fallback '%s' makes a cycle", remoteConfig.Fallback)
+		}
+		if err != nil {
+			return nil, file, countStorageError(&merrors.CorruptDataError{Path: file, Err: err})
+		}
+		file = fallback
+		zerolog.Ctx(request.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("fallback", file)
+		})
+	}
+}
+
+// applyOverlays merges the overlays of the channel, organization and cluster
+// of the request into the remote configuration. The merged overlays are
+// counted only when the remote configuration is served.
+func (r *Repository) applyOverlays(request *http.Request, isCanary bool, remoteConfig *RemoteConfiguration, now time.Time, served bool) {
+	keys := OverlayKeys{Channel: StableVersion}
+	if isCanary {
+		keys.Channel = CanaryVersion
+	}
+	keys.ClusterID = ParseUserAgent(request.UserAgent()).ClusterID
+	if identity, ok := request.Context().Value(server.ContextKeyUser).(server.Identity); ok && identity.Internal.OrgID != 0 {
+		keys.OrgID = strconv.FormatUint(uint64(identity.Internal.OrgID), 10)
+	}
+
+	overlays := r.store.Overlays(keys)
+	if len(overlays) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(overlays))
+	for _, overlay := range overlays {
+		// the overlays are shared by the requests, so the active entries
+		// are merged from a copy
+		active := *overlay
+		active.ConditionalRules, _ = filterActive(overlay.ConditionalRules, now, ruleActivation)
+		active.ContainerLogsRequests, _ = filterActive(overlay.ContainerLogsRequests, now, containerLogsActivation)
+		active.Apply(remoteConfig)
+		names = append(names, overlay.Name)
+		if served {
+			overlaysMetric.WithLabelValues(strings.Split(overlay.Name, "/")[0]).Inc()
+		}
+	}
+	zerolog.Ctx(request.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Strs("overlays", names)
+	})
+}
+
+// ExpiredEntries returns the entries with ended activation window from the
+// rules of both channels, all the remote configurations of the cluster
+// mappings and the overlays. The expired entries metric is updated for every
+// scanned source.
+func (r *Repository) ExpiredEntries(ctx context.Context) ([]ExpiredEntry, error) {
+	now := r.clock()
+	expired := []ExpiredEntry{}
+
+	filepath := "rules.json"
+	for _, isCanary := range []bool{false, true} {
+		data, err := r.store.ReadConditionalRules(ctx, isCanary, filepath)
+		var notFoundErr *merrors.StorageNotFoundError
+		if errors.As(err, &notFoundErr) || (err == nil && data == nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var rules Rules
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, &merrors.CorruptDataError{Path: filepath, Err: err}
+		}
+		source := rulesSource(isCanary, filepath)
+		found := findExpired(rules.Items, now, source, EntryKindRule, ruleActivation)
+		expiredEntriesMetric.WithLabelValues(source).Set(float64(len(found)))
+		expired = append(expired, found...)
+	}
+
+	for _, path := range r.store.RemoteConfigurationFilepaths() {
+		data, err := r.store.ReadRemoteConfig(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		var remoteConfig RemoteConfiguration
+		if err := json.Unmarshal(data, &remoteConfig); err != nil {
+			return nil, &merrors.CorruptDataError{Path: path, Err: err}
+		}
+		found := expiredInRemoteConfiguration(&remoteConfig, now, path)
+		expiredEntriesMetric.WithLabelValues(path).Set(float64(len(found)))
+		expired = append(expired, found...)
+	}
+
+	for _, overlay := range r.store.AllOverlays() {
+		found := expiredInRemoteConfiguration(&RemoteConfiguration{
+			ConditionalRules:      overlay.ConditionalRules,
+			ContainerLogsRequests: overlay.ContainerLogsRequests,
+		}, now, overlay.Name)
+		expiredEntriesMetric.WithLabelValues(overlay.Name).Set(float64(len(found)))
+		expired = append(expired, found...)
+	}
+
+	return expired, nil
+}
+
+// rulesSource returns the source name of the rules file of the channel
+func rulesSource(isCanary bool, file string) string {
+	if isCanary {
+		return CanaryVersion + "/" + file
+	}
+	return StableVersion + "/" + file
+}
+
+// supportedRules returns the rules supported by the capabilities of the
+// client. The number of the stripped rules is added to the access log and the
+// stripped rules are counted by the metrics when they're served.
+func supportedRules(request *http.Request, rules []Rule, served bool) []Rule {
+	supported, stripped := ParseCapabilities(request).supportedRules(rules, served)
+	if stripped > 0 {
+		zerolog.Ctx(request.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Int("strippedRules", stripped)
+		})
+	}
+	return supported
+}
+
+// allowedRules returns the rules not denied by the kill switch. The number of
+// the denied rules is added to the access log.
+func (r *Repository) allowedRules(request *http.Request, rules []Rule) []Rule {
+	allowed, denied := r.killSwitch.Apply(rules)
+	if denied > 0 {
+		zerolog.Ctx(request.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Int("deniedRules", denied)
+		})
+	}
+	return allowed
+}
+
+// countStorageError updates the storage errors metric by the kind of given
+// error and returns the error
+func countStorageError(err error) error {
+	var (
+		notFoundErr *merrors.StorageNotFoundError
+		corruptErr  *merrors.CorruptDataError
+	)
+	switch {
+	case errors.As(err, &notFoundErr):
+		storageErrorsMetric.WithLabelValues(storageErrorNotFound).Inc()
+	case errors.As(err, &corruptErr):
+		storageErrorsMetric.WithLabelValues(storageErrorCorrupt).Inc()
+	default:
+		storageErrorsMetric.WithLabelValues(storageErrorIO).Inc()
+	}
+	return err
+}
+
+// logRequestDetails adds the cluster ID, channel, OCP version and the served
+// file to the request scoped logger, so they are part of the access log
+func logRequestDetails(request *http.Request, isCanary bool, ocpVersion, file string) {
+	channel := StableVersion
+	if isCanary {
+		channel = CanaryVersion
+	}
+	userAgent := ParseUserAgent(request.UserAgent())
 
-	return &remoteConfig, nil
+	zerolog.Ctx(request.Context()).UpdateContext(func(c zerolog.Context) zerolog.Context {
+		c = c.Str("clusterID", userAgent.ClusterID).Str("channel", channel)
+		if userAgent.OperatorVersion != "" {
+			c = c.Str("operatorVersion", userAgent.OperatorVersion)
+		}
+		if ocpVersion != "" {
+			c = c.Str("ocpVersion", ocpVersion)
+		}
+		if file != "" {
+			c = c.Str("file", file)
+		}
+		return c
+	})
 }
@@ -17,10 +17,17 @@ limitations under the License.
 package service_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,7 +35,9 @@ func TestRepositoryRules(t *testing.T) {
 	type testCase struct {
 		name                 string
 		mockConditionalRules []byte
+		mockError            error
 		expectedAnError      bool
+		expectedErrorKind    string
 		expectedRules        service.Rules
 	}
 
@@ -37,11 +46,19 @@ func TestRepositoryRules(t *testing.T) {
 			name:                 "unparsable rule",
 			mockConditionalRules: []byte("not a JSON"),
 			expectedAnError:      true,
+			expectedErrorKind:    "corrupt",
 		},
 		{
 			name:                 "no data returned by storage",
 			mockConditionalRules: nil,
 			expectedAnError:      true,
+			expectedErrorKind:    "not_found",
+		},
+		{
+			name:              "storage failure",
+			mockError:         &merrors.StorageIOError{Path: "rules.json", Err: errors.New("I/O error")},
+			expectedAnError:   true,
+			expectedErrorKind: "io",
 		},
 		{
 			name:                 "valid rules returned by storage",
@@ -54,11 +71,14 @@ func TestRepositoryRules(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			m := mockStorage{
 				conditionalRules: tc.mockConditionalRules,
+				readMockError:    tc.mockError,
 			}
 			r := service.NewRepository(&m)
+			failures := testutil.ToFloat64(service.StorageErrorsMetric.WithLabelValues(tc.expectedErrorKind))
 			rules, err := r.Rules(&http.Request{})
 			if tc.expectedAnError {
 				assert.Error(t, err)
+				assert.Equal(t, failures+1, testutil.ToFloat64(service.StorageErrorsMetric.WithLabelValues(tc.expectedErrorKind)))
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, &tc.expectedRules, rules)
@@ -74,17 +94,20 @@ func TestRepositoryRemoteConfiguration(t *testing.T) {
 		name                 string
 		mockRemoteConfig     []byte
 		expectedAnError      bool
+		expectedError        error
 		expectedRemoteConfig service.RemoteConfiguration
 	}{
 		{
 			name:             "unparsable rule",
 			mockRemoteConfig: []byte("not a JSON"),
 			expectedAnError:  true,
+			expectedError:    &merrors.CorruptDataError{},
 		},
 		{
 			name:             "no data returned by storage",
 			mockRemoteConfig: nil,
 			expectedAnError:  true,
+			expectedError:    &merrors.StorageNotFoundError{},
 		},
 		{
 			name:                 "valid remote configuration returned by storage",
@@ -97,16 +120,45 @@ func TestRepositoryRemoteConfiguration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := mockStorage{
-				remoteConfig: tt.mockRemoteConfig,
+				remoteConfig:         tt.mockRemoteConfig,
+				remoteConfigFilepath: "canary/config.json",
 			}
 			r := service.NewRepository(&m)
 			remoteConfig, err := r.RemoteConfiguration(&http.Request{}, anyVer)
 			if tt.expectedAnError {
-				assert.Error(t, err)
+				assert.IsType(t, tt.expectedError, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, &tt.expectedRemoteConfig, remoteConfig)
+				expectedRemoteConfig := tt.expectedRemoteConfig
+				expectedRemoteConfig.Origin = &service.ConfigurationOrigin{Channel: service.CanaryVersion, File: "config.json"}
+				assert.Equal(t, &expectedRemoteConfig, remoteConfig)
 			}
 		})
 	}
 }
+
+// TestRepositoryLogsRequestDetails checks that the details of served
+// configuration are added to the request scoped logger
+func TestRepositoryLogsRequestDetails(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	m := mockStorage{
+		remoteConfig:         []byte(validStableRemoteConfigurationJSON),
+		remoteConfigFilepath: "stable/experimental_1.json",
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v2/4.17.0/gathering_rules", http.NoBody)
+	req.Header.Set("User-Agent", canaryUserAgent)
+	req = req.WithContext(logger.WithContext(req.Context()))
+
+	_, err := service.NewRepository(&m).RemoteConfiguration(req, "4.17.0")
+	assert.NoError(t, err)
+
+	zerolog.Ctx(req.Context()).Info().Msg("served")
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, canaryClusterID, entry["clusterID"])
+	assert.Equal(t, service.CanaryVersion, entry["channel"])
+	assert.Equal(t, "4.17.0", entry["ocpVersion"])
+	assert.Equal(t, "stable/experimental_1.json", entry["file"])
+}
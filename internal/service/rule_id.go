@@ -0,0 +1,99 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// contentIDLength is the number of hex digits of the derived rule IDs
+const contentIDLength = 16
+
+// RuleMetadata structure describes the provenance of a rule. It's internal
+// information, which can be stripped from the served rules.
+type RuleMetadata struct {
+	Owner  string `json:"owner,omitempty"`
+	Ticket string `json:"ticket,omitempty"`
+	// Created is the creation date, like 2026-01-31
+	Created string `json:"created,omitempty"`
+}
+
+// Validate checks the creation date
+func (m *RuleMetadata) Validate() error {
+	if m == nil || m.Created == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.DateOnly, m.Created); err != nil {
+		return fmt.Errorf("metadata.created must be a date like 2026-01-31: %w", err)
+	}
+	return nil
+}
+
+// RuleID returns the ID of the rule. The ID of a rule without explicit ID
+// is derived from its conditions and gathering functions, so it's the same
+// for the same content.
+func (rule *Rule) RuleID() string {
+	if rule.ID != "" {
+		return rule.ID
+	}
+
+	// the keys of JSON objects are sorted by the encoder
+	content, err := json.Marshal(struct {
+		Conditions         []interface{} `json:"conditions"`
+		GatheringFunctions interface{}   `json:"gathering_functions"`
+	}{rule.Conditions, rule.GatheringFunctions})
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])[:contentIDLength]
+}
+
+// validateRuleIDs checks the explicit rule IDs are unique and the metadata
+// are valid
+func validateRuleIDs(rules []Rule) map[int]error {
+	invalid := map[int]error{}
+	firstIndex := map[string]int{}
+	for i := range rules {
+		var errs []error
+		if id := rules[i].ID; id != "" {
+			if first, found := firstIndex[id]; found {
+				errs = append(errs, fmt.Errorf("id '%s' is already used by rule %d", id, first))
+			} else {
+				firstIndex[id] = i
+			}
+		}
+		errs = append(errs, rules[i].Metadata.Validate())
+		if err := errors.Join(errs...); err != nil {
+			invalid[i] = err
+		}
+	}
+	return invalid
+}
+
+// stripRuleMetadata removes the metadata from the rules. The IDs are kept,
+// they're needed to refer to the rules.
+func stripRuleMetadata(rules []Rule) {
+	for i := range rules {
+		rules[i].Metadata = nil
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+const ruleMetadataConfigJSON = `{
+	"version": "1.1.0",
+	"conditional_gathering_rules": [
+		{
+			"id": "pod-not-ready",
+			"conditions": [{"type":"alert_is_firing","alert":{"name":"KubePodNotReady"}}],
+			"gathering_functions": {"pod_definition":{"alert_name":"KubePodNotReady"}},
+			"metadata": {"owner":"ccx-team","ticket":"https://issues.redhat.com/browse/CCXDEV-1","created":"2026-01-31"}
+		},
+		{
+			"conditions": [{"type":"alert_is_firing","alert":{"name":"KubePodCrashLooping"}}],
+			"gathering_functions": {"containers_logs":{"alert_name":"KubePodCrashLooping","tail_lines":20}},
+			"metadata": {"owner":"ccx-team"}
+		}
+	],
+	"container_logs": []
+}`
+
+func unmarshalRule(t *testing.T, data string) service.Rule {
+	var rule service.Rule
+	require.NoError(t, json.Unmarshal([]byte(data), &rule))
+	return rule
+}
+
+func TestRuleID(t *testing.T) {
+	rule := unmarshalRule(t, `{
+		"conditions": [{"type":"alert_is_firing","alert":{"name":"KubePodNotReady"}}],
+		"gathering_functions": {"pod_definition":{"alert_name":"KubePodNotReady"}}
+	}`)
+	derivedID := rule.RuleID()
+	assert.Regexp(t, "^[0-9a-f]{16}$", derivedID)
+
+	// the order of the keys, the activation window and the metadata don't
+	// change the derived ID
+	reordered := unmarshalRule(t, `{
+		"metadata": {"owner":"ccx-team"},
+		"active_until": "2030-01-01T00:00:00Z",
+		"gathering_functions": {"pod_definition":{"alert_name":"KubePodNotReady"}},
+		"conditions": [{"alert":{"name":"KubePodNotReady"},"type":"alert_is_firing"}]
+	}`)
+	assert.Equal(t, derivedID, reordered.RuleID())
+
+	other := unmarshalRule(t, `{
+		"conditions": [{"type":"alert_is_firing","alert":{"name":"KubePodCrashLooping"}}],
+		"gathering_functions": {"pod_definition":{"alert_name":"KubePodCrashLooping"}}
+	}`)
+	assert.NotEqual(t, derivedID, other.RuleID())
+
+	rule.ID = "pod-not-ready"
+	assert.Equal(t, "pod-not-ready", rule.RuleID())
+}
+
+func TestDenyListDeniesDerivedID(t *testing.T) {
+	rule := alertRule("KubePodNotReady", 10)
+
+	assert.True(t, service.DenyList{RuleIDs: []string{rule.RuleID()}}.Denies(rule))
+	assert.False(t, service.DenyList{RuleIDs: []string{"pod-not-ready"}}.Denies(rule))
+}
+
+func TestRuleMetadataValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata *service.RuleMetadata
+		valid    bool
+	}{
+		{"no metadata", nil, true},
+		{"no creation date", &service.RuleMetadata{Owner: "ccx-team"}, true},
+		{"creation date", &service.RuleMetadata{Created: "2026-01-31"}, true},
+		{"creation time", &service.RuleMetadata{Created: "2026-01-31T10:00:00Z"}, false},
+		{"invalid creation date", &service.RuleMetadata{Created: "2026-02-31"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.metadata.Validate()
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+// TestStorageInvalidRuleIDs checks that the duplicate rule IDs and the
+// invalid metadata are reported at load time
+func TestStorageInvalidRuleIDs(t *testing.T) {
+	_, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: "testdata/v2_invalid_rule_ids",
+	}, false, nil)
+
+	var invalidErr *merrors.InvalidEntryError
+	require.ErrorAs(t, err, &invalidErr)
+	assert.ErrorContains(t, err, "conditional_gathering_rules[1] of 'testdata/v2_invalid_rule_ids/stable/rules.json' is invalid: id 'pod-not-ready' is already used by rule 0")
+	assert.ErrorContains(t, err, "metadata.created must be a date")
+	assert.NotContains(t, err.Error(), "conditional_gathering_rules[0]")
+}
+
+func TestRepositoryStripMetadata(t *testing.T) {
+	for _, strip := range []bool{false, true} {
+		store := mockStorage{
+			remoteConfig:         []byte(ruleMetadataConfigJSON),
+			remoteConfigFilepath: "config.json",
+		}
+		repo := service.NewRepository(&store)
+		repo.SetStripMetadata(strip)
+		repo.SetClock(func() time.Time { return activationNow })
+
+		remoteConfig, err := repo.RemoteConfiguration(httptest.NewRequest(http.MethodGet, "/", http.NoBody), "4.17.0")
+		require.NoError(t, err)
+		require.Len(t, remoteConfig.ConditionalRules, 2)
+
+		// the IDs are kept, only the missing ones are not added
+		assert.Equal(t, "pod-not-ready", remoteConfig.ConditionalRules[0].ID)
+		assert.Empty(t, remoteConfig.ConditionalRules[1].ID)
+		if strip {
+			assert.Nil(t, remoteConfig.ConditionalRules[0].Metadata)
+			assert.Nil(t, remoteConfig.ConditionalRules[1].Metadata)
+		} else {
+			assert.Equal(t, &service.RuleMetadata{
+				Owner:   "ccx-team",
+				Ticket:  "https://issues.redhat.com/browse/CCXDEV-1",
+				Created: "2026-01-31",
+			}, remoteConfig.ConditionalRules[0].Metadata)
+		}
+	}
+}
@@ -17,12 +17,25 @@ limitations under the License.
 // Package service provides the functionality of this service
 package service
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 // RulesProvider defines methods to be implemented by any rules provider
 type RulesProvider interface {
 	Rules(r *http.Request) (*Rules, error)
 	RemoteConfiguration(r *http.Request, ocpVersion string) (*RemoteConfiguration, error)
+	ResolveRemoteConfiguration(r *http.Request, ocpVersion string) (*RemoteConfiguration, error)
+	ExpiredEntries(ctx context.Context) ([]ExpiredEntry, error)
+	DenyList() DenyList
+	UpdateDenyList(denyList DenyList) error
+	ReleaseState() ReleaseState
+	UpdateRelease(operation string) (ReleaseState, error)
+	ReportFeedback(r *http.Request, feedback *Feedback) error
+	LedgerRecords(ctx context.Context, filter LedgerFilter) ([]LedgerRecord, error)
+	UnmappedVersions(top int) []UnmappedVersion
+	Ready(ctx context.Context) error
 }
 
 // Service data type represents the whole service for repository interface.
@@ -56,3 +69,66 @@ func (s *Service) RemoteConfiguration(r *http.Request, ocpVersion string) (*Remo
 
 	return remoteConfiguration, nil
 }
+
+// ResolveRemoteConfiguration method returns the remote configuration without
+// recording it as served to the cluster or updating the metrics.
+func (s *Service) ResolveRemoteConfiguration(r *http.Request, ocpVersion string) (*RemoteConfiguration, error) {
+	return s.repo.ResolveRemoteConfiguration(r, ocpVersion)
+}
+
+// ExpiredEntries method returns the stored entries with ended activation
+// window.
+func (s *Service) ExpiredEntries(ctx context.Context) ([]ExpiredEntry, error) {
+	return s.repo.ExpiredEntries(ctx)
+}
+
+// DenyList method returns the deny-list of the served rules.
+func (s *Service) DenyList() DenyList {
+	return s.repo.DenyList()
+}
+
+// UpdateDenyList method replaces the deny-list of the served rules.
+func (s *Service) UpdateDenyList(denyList DenyList) error {
+	return s.repo.UpdateDenyList(denyList)
+}
+
+// ReleaseState method returns the snapshots served to the channels.
+func (s *Service) ReleaseState() ReleaseState {
+	return s.repo.ReleaseState()
+}
+
+// UpdateRelease method changes the snapshots served to the channels by the
+// operation.
+func (s *Service) UpdateRelease(operation string) (ReleaseState, error) {
+	return s.repo.UpdateRelease(operation)
+}
+
+// ReportFeedback method aggregates the outcome of applying the remote
+// configuration reported by a cluster.
+func (s *Service) ReportFeedback(r *http.Request, feedback *Feedback) error {
+	return s.repo.ReportFeedback(r, feedback)
+}
+
+// LedgerRecords method returns the remote configurations served to the
+// clusters selected by the filter.
+func (s *Service) LedgerRecords(ctx context.Context, filter LedgerFilter) ([]LedgerRecord, error) {
+	return s.repo.LedgerRecords(ctx, filter)
+}
+
+// UnmappedVersions method returns the most requested OCP versions not
+// covered by the cluster mappings.
+func (s *Service) UnmappedVersions(top int) []UnmappedVersion {
+	return s.repo.UnmappedVersions(top)
+}
+
+// Ready method returns an error when the service is not ready to serve the
+// remote configurations.
+func (s *Service) Ready(ctx context.Context) error {
+	return s.repo.Ready(ctx)
+}
+
+// Close method releases the resources of the service, like writing the
+// queued ledger records.
+func (s *Service) Close() error {
+	return s.repo.Close()
+}
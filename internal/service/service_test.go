@@ -22,6 +22,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -153,6 +154,55 @@ func TestServiceV2(t *testing.T) {
 	}
 }
 
+// TestServiceStorageErrors checks the status codes of responses when the
+// data can't be read from the storage
+func TestServiceStorageErrors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		store          mockStorage
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "rules not found",
+			store:          mockStorage{},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   `"code":"not_found"`,
+		},
+		{
+			name: "rules can't be read",
+			store: mockStorage{
+				readMockError: &merrors.StorageIOError{Path: "rules.json", Err: fmt.Errorf("I/O error")},
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   `"code":"unavailable"`,
+		},
+		{
+			name: "rules are corrupt",
+			store: mockStorage{
+				conditionalRules: []byte(`{"version": 1}`),
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   `"code":"internal_error"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := service.NewHandler(service.New(service.NewRepository(&tc.store)))
+			router := mux.NewRouter()
+			handler.Register(router)
+
+			req := httptest.NewRequest(http.MethodGet, service.APIPrefix+"/gathering_rules", http.NoBody)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.expectedCode)
+		})
+	}
+}
+
 func TestServiceV2WithClusterMapping(t *testing.T) {
 	type testCase struct {
 		name              string
@@ -166,7 +216,6 @@ func TestServiceV2WithClusterMapping(t *testing.T) {
 
 	const (
 		validRemoteConfigsPath = "../../tests/rapid-recommendations/valid"
-		internalServerError    = `{"status":"Internal Server Error"}`
 	)
 
 	testCases := []testCase{
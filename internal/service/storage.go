@@ -17,21 +17,27 @@ limitations under the License.
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Unleash/unleash-go-sdk/v6"
-	"github.com/Unleash/unleash-go-sdk/v6/context"
+	unleashcontext "github.com/Unleash/unleash-go-sdk/v6/context"
 
 	"github.com/blang/semver/v4"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
 )
@@ -51,15 +57,35 @@ type UnleashClientInterface interface {
 // implementations.
 type StorageInterface interface {
 	IsCanary(request *http.Request) bool
-	ReadConditionalRules(isCanary bool, res string) []byte
-	ReadRemoteConfig(p string) []byte
-	GetRemoteConfigurationFilepath(isCanary bool, ocpVersion string) (string, error)
+	ReadConditionalRules(ctx context.Context, isCanary bool, res string) ([]byte, error)
+	ReadRemoteConfig(ctx context.Context, p string) ([]byte, error)
+	GetRemoteConfigurationFilepath(ctx context.Context, isCanary bool, ocpVersion string) (string, error)
+	RecordOCPVersion(isCanary bool, ocpVersion string)
+	Overlays(keys OverlayKeys) []*Overlay
+	AllOverlays() []*Overlay
+	RemoteConfigurationFilepaths() []string
+	InheritedFrom(path string) string
+	UnmappedVersions(top int) []UnmappedVersion
 }
 
 // StorageConfig structure contains configuration for resource storage.
 type StorageConfig struct {
 	RulesPath                string `mapstructure:"rules_path" toml:"rules_path"`
 	RemoteConfigurationsPath string `mapstructure:"remote_configurations" toml:"remote_configurations"`
+	// OverlaysPath is the directory with the overlays of the remote
+	// configurations, the overlays are disabled when it's empty
+	OverlaysPath string `mapstructure:"overlays_path" toml:"overlays_path"`
+	// DenyListPath is the file with the deny-list of the rules, it's
+	// reloaded when it changes. The deny-list is kept only in memory when
+	// it's empty. The updates of the deny-list fail when the file is not
+	// writable, like in a config map mount.
+	DenyListPath string `mapstructure:"deny_list_path" toml:"deny_list_path"`
+	// StripRuleMetadata removes the internal metadata of the rules, like
+	// the owner, before the rules are served
+	StripRuleMetadata bool `mapstructure:"strip_rule_metadata" toml:"strip_rule_metadata"`
+	// ReleaseStatePath is the file keeping the snapshots served to the
+	// channels. The state is kept only in memory when it's empty.
+	ReleaseStatePath string `mapstructure:"release_state_path" toml:"release_state_path"`
 }
 
 // CanaryConfig structure contains configuration for canary rollout
@@ -114,7 +140,7 @@ func NewUnleashClient(cfg CanaryConfig) (*UnleashClient, error) {
 
 // IsCanary queries Unleash to determine whether to serve stable or canary version of data
 func (c *UnleashClient) IsCanary(canaryArgument string) bool {
-	return unleash.IsEnabled(c.unleashToggle, unleash.FeatureOptions{Ctx: context.Context{UserId: canaryArgument}})
+	return unleash.IsEnabled(c.unleashToggle, unleash.FeatureOptions{Ctx: unleashcontext.Context{UserId: canaryArgument}})
 }
 
 // Storage type represents container for resources.
@@ -126,6 +152,15 @@ type Storage struct {
 	canaryClusterMapping     *ClusterMapping
 	unleashClient            UnleashClientInterface
 	unleashEnabled           bool
+	overlays                 map[string]*Overlay
+	// remoteConfigurationFilepaths are set by the validation on load
+	remoteConfigurationFilepaths []string
+	// channelParents holds the parent channels declared by the channels
+	channelParents map[string]string
+	// inheritedFiles holds the parent channels of the files read from them
+	inheritedFiles sync.Map
+	// fleet counts the requested OCP versions
+	fleet fleetVersions
 }
 
 // NewStorage constructs new storage object.
@@ -136,54 +171,108 @@ func NewStorage(storageConfig StorageConfig, unleashEnabled bool, unleashClient
 		remoteConfigurationsPath: storageConfig.RemoteConfigurationsPath,
 		unleashEnabled:           unleashEnabled,
 		unleashClient:            unleashClient,
+		channelParents:           map[string]string{},
 	}
 
-	cm, err := s.loadClusterMapping(StableVersion)
+	cm, err := s.loadClusterMapping(StableVersion, nil)
 	if err != nil {
 		log.Error().Err(err).Msg("Could not load stable version of cluster mapping")
 		return &s, err
 	}
 	s.stableClusterMapping = cm
 
-	cm, err = s.loadClusterMapping(CanaryVersion)
+	cm, err = s.loadClusterMapping(CanaryVersion, nil)
 	if err != nil {
 		log.Error().Err(err).Msg("Could not load canary version of cluster mapping")
 		return &s, err
 	}
 	s.canaryClusterMapping = cm
 
+	if err := s.validateRemoteConfigurations(); err != nil {
+		log.Error().Err(err).Msg("Invalid remote configurations")
+		return &s, err
+	}
+
+	if storageConfig.OverlaysPath != "" {
+		overlays, err := loadOverlays(storageConfig.OverlaysPath)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not load the overlays")
+			return &s, err
+		}
+		s.overlays = overlays
+	}
+
 	return &s, nil
 }
 
-func (s *Storage) loadClusterMapping(version string) (*ClusterMapping, error) {
+// loadClusterMapping loads the cluster mapping of the channel. The mapping of
+// a channel with parent is merged with the one of the parent, the children
+// are the channels whose parent is being loaded.
+func (s *Storage) loadClusterMapping(version string, children []string) (*ClusterMapping, error) {
 	if s.remoteConfigurationsPath == "" {
 		errStr := "remote configurations directory path is not defined"
 		log.Error().Msg(errStr)
 		return nil, errors.New(errStr)
 	}
 
+	channelConfig, err := s.loadChannelConfig(version, children)
+	if err != nil {
+		log.Error().Str("version", version).Err(err).Msg("Cannot load channel configuration")
+		return nil, err
+	}
+	var parent *ClusterMapping
+	if channelConfig.Parent != "" {
+		parent = s.loadedClusterMapping(channelConfig.Parent)
+		if parent == nil {
+			parent, err = s.loadClusterMapping(channelConfig.Parent, append(children, version))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	configsRootDir := filepath.Join(s.remoteConfigurationsPath, version)
 
 	// Parse the cluster map
 	cm := ClusterMapping{
 		rootDir: configsRootDir,
 		mapping: [][]string{},
+		parent:  parent,
 	}
 
 	fullFilepath := filepath.Join(configsRootDir, "cluster_version_mapping.json")
 	log.Info().Msg(fullFilepath)
-	rawData := s.readDataFromPath(fullFilepath)
-	if rawData == nil {
-		return nil, errors.New("cannot find cluster map")
+	rawData, err := s.readDataFromPath(context.Background(), fullFilepath)
+	var notFoundErr *merrors.StorageNotFoundError
+	switch {
+	case parent != nil && errors.As(err, &notFoundErr):
+		// the whole mapping is inherited
+		rawData = []byte("[]")
+	case err != nil:
+		log.Error().Str("version", version).Err(err).Msg("Cannot find cluster map")
+		return nil, err
 	}
-	err := json.Unmarshal(rawData, &cm.mapping)
+	err = json.Unmarshal(rawData, &cm.mapping)
 	if err != nil {
 		log.Error().Str("version", version).Err(err).Msg("Cannot load cluster map")
-		return nil, err
+		return nil, &merrors.CorruptDataError{Path: fullFilepath, Err: err}
 	}
 
 	log.Debug().Interface("cluster-map", cm.mapping).Msg("Cluster map loaded")
 
+	if parent != nil {
+		// the parent is set after the own mapping is read, so the mapping
+		// of the parent isn't read instead of the missing one
+		s.channelParents[version] = channelConfig.Parent
+		// the own entries of a child are validated before they are merged
+		if len(cm.mapping) > 0 && !cm.IsValid() {
+			log.Error().Str("version", version).Msg("The cluster map is invalid")
+			return nil, errors.New("cannot parse cluster map")
+		}
+		cm.mapping = mergeClusterMappings(cm.mapping, parent.mapping)
+		log.Debug().Str("parent", channelConfig.Parent).Interface("cluster-map", cm.mapping).Msg("Cluster map merged with the parent")
+	}
+
 	if cm.IsValid() {
 		log.Info().Str("version", version).Msg("The cluster map JSON is valid")
 	} else {
@@ -194,6 +283,15 @@ func (s *Storage) loadClusterMapping(version string) (*ClusterMapping, error) {
 	return &cm, nil
 }
 
+// loadedClusterMapping returns the cluster mapping of the channel if it's
+// already loaded
+func (s *Storage) loadedClusterMapping(version string) *ClusterMapping {
+	if version == CanaryVersion {
+		return s.canaryClusterMapping
+	}
+	return s.stableClusterMapping
+}
+
 // IsCanary queries UnleashClient to determine which version of configurations to serve
 func (s *Storage) IsCanary(r *http.Request) bool {
 	if !s.unleashEnabled {
@@ -201,7 +299,14 @@ func (s *Storage) IsCanary(r *http.Request) bool {
 	}
 	// We use User-Agent header to decide between stable and canary version (header contains cluster ID)
 	clusterID := GetClusterID(r)
+
+	_, span := tracer.Start(r.Context(), "unleash.IsCanary")
 	isCanary := s.unleashClient.IsCanary(clusterID)
+	span.SetAttributes(
+		attribute.String("cluster.id", clusterID),
+		attribute.Bool("canary", isCanary),
+	)
+	span.End()
 	if isCanary {
 		log.Debug().Str("canary argument", clusterID).Msg("Serving canary version of configurations")
 	} else {
@@ -211,25 +316,34 @@ func (s *Storage) IsCanary(r *http.Request) bool {
 }
 
 // ReadConditionalRules tries to find conditional rule with given name in the storage.
-func (s *Storage) ReadConditionalRules(isCanary bool, path string) []byte {
+func (s *Storage) ReadConditionalRules(ctx context.Context, isCanary bool, path string) ([]byte, error) {
 	log.Debug().Str("path to resource", path).Msg("Finding resource")
 	version := StableVersion
 	if isCanary {
 		version = CanaryVersion
 	}
 	conditionalRulesPath := filepath.Join(s.conditionalRulesPath, version, path)
-	return s.readDataFromPath(conditionalRulesPath)
+	return s.readDataFromPath(ctx, conditionalRulesPath)
 }
 
 // ReadRemoteConfig tries to find remote configuration with given path in the storage
-func (s *Storage) ReadRemoteConfig(path string) []byte {
+func (s *Storage) ReadRemoteConfig(ctx context.Context, path string) ([]byte, error) {
 	log.Debug().Str("path to resource", path).Msg("Finding resource")
-	return s.readDataFromPath(path)
+	return s.readDataFromPath(ctx, path)
 }
 
 // GetRemoteConfigurationFilepath returns the filepath to the remote configuration
 // that should be returned for the given OCP version based on the cluster map
-func (s *Storage) GetRemoteConfigurationFilepath(isCanary bool, ocpVersion string) (string, error) {
+func (s *Storage) GetRemoteConfigurationFilepath(ctx context.Context, isCanary bool, ocpVersion string) (file string, err error) {
+	_, span := tracer.Start(ctx, "Storage.GetRemoteConfigurationFilepath", trace.WithAttributes(
+		attribute.String("ocp.version", ocpVersion),
+		attribute.Bool("canary", isCanary),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("storage.path", file))
+		endSpan(span, err)
+	}()
+
 	ocpVersionParsed, err := semver.Make(ocpVersion)
 	if err != nil {
 		log.Info().Str("ocpVersion", ocpVersion).Err(err).Msg("Invalid semver")
@@ -245,23 +359,69 @@ func (s *Storage) GetRemoteConfigurationFilepath(isCanary bool, ocpVersion strin
 	return s.stableClusterMapping.GetFilepathForVersion(ocpVersionParsed)
 }
 
-func (s *Storage) readDataFromPath(path string) []byte {
+// RecordOCPVersion counts the OCP version requested from the channel by the
+// fleet metrics and, when it's not covered by the cluster mapping, for the
+// report of the unmapped versions. Invalid versions are not counted.
+func (s *Storage) RecordOCPVersion(isCanary bool, ocpVersion string) {
+	version, err := semver.Make(ocpVersion)
+	if err != nil {
+		return
+	}
+	channel, cm := StableVersion, s.stableClusterMapping
+	if isCanary {
+		channel, cm = CanaryVersion, s.canaryClusterMapping
+	}
+	s.fleet.record(channel, cm.versionLabel(version), cm.coverage(version), time.Now())
+}
+
+// UnmappedVersions returns the most requested OCP versions not covered by
+// the cluster mappings
+func (s *Storage) UnmappedVersions(top int) []UnmappedVersion {
+	return s.fleet.top(top)
+}
+
+// readDataFromPath returns the content of given file. Missing files are
+// reported by *merrors.StorageNotFoundError, other failures by
+// *merrors.StorageIOError.
+func (s *Storage) readDataFromPath(ctx context.Context, path string) (data []byte, err error) {
+	_, span := tracer.Start(ctx, "Storage.readDataFromPath", trace.WithAttributes(
+		attribute.String("storage.path", path),
+	))
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	// use the in-memory data
-	data := s.cache.Get(path)
+	data = s.cache.Get(path)
 	if data != nil {
-		return data
+		span.SetAttributes(attribute.Bool("storage.cache_hit", true))
+		return data, nil
 	}
+	span.SetAttributes(attribute.Bool("storage.cache_hit", false))
 
-	// or try to load it from the file
-	data, err := s.readFile(path)
-	if err != nil {
+	// or try to load it from the file, the files missing in a channel are
+	// read from its parent
+	filePath, inheritedFrom := s.channelFilepath(path)
+	data, err = s.readFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
 		log.Warn().Msgf("Resource not found: '%s'", path)
-		return nil
+		return nil, &merrors.StorageNotFoundError{Path: path}
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Resource can't be read: '%s'", path)
+		return nil, &merrors.StorageIOError{Path: path, Err: err}
 	}
 
 	log.Debug().Int("bytes", len(data)).Msg("Resource file has been read")
 
-	return data
+	// add the bytes to cache
+	s.cache.Set(path, data)
+	if inheritedFrom != "" {
+		span.SetAttributes(attribute.String("storage.inherited_from", inheritedFrom))
+		s.inheritedFiles.Store(path, inheritedFrom)
+	}
+
+	return data, nil
 }
 
 func (s *Storage) readFile(path string) ([]byte, error) {
@@ -276,29 +436,39 @@ func (s *Storage) readFile(path string) ([]byte, error) {
 		}
 	}()
 
-	data, err := io.ReadAll(f)
-	if err != nil {
-		return nil, err
-	}
+	return io.ReadAll(f)
+}
 
-	// add the bytes to cache
-	s.cache.Set(path, data)
+// Overlays returns the overlays for given keys in the order they have to be
+// applied
+func (s *Storage) Overlays(keys OverlayKeys) []*Overlay {
+	return selectOverlays(s.overlays, keys)
+}
 
-	return data, nil
+// AllOverlays returns all the loaded overlays sorted by their names
+func (s *Storage) AllOverlays() []*Overlay {
+	overlays := make([]*Overlay, 0, len(s.overlays))
+	for _, overlay := range s.overlays {
+		overlays = append(overlays, overlay)
+	}
+	slices.SortFunc(overlays, func(a, b *Overlay) int { return strings.Compare(a.Name, b.Name) })
+	return overlays
+}
+
+// RemoteConfigurationFilepaths returns the sorted filepaths of all the remote
+// configurations referenced by the cluster mappings of both channels and by
+// their fallbacks
+func (s *Storage) RemoteConfigurationFilepaths() []string {
+	return s.remoteConfigurationFilepaths
 }
 
 // GetClusterID obtain the cluster ID from user agent
 func GetClusterID(r *http.Request) string {
 	userAgent := r.UserAgent()
-	if !strings.Contains(userAgent, "cluster/") {
+	clusterID := ParseUserAgent(userAgent).ClusterID
+	if clusterID == "" {
 		err := errors.New("UserAgent does not contain cluster ID")
 		log.Warn().Str("UserAgent", userAgent).Err(err).Msg("Failed to retrieve cluster ID")
-		return ""
 	}
-	_, clusterID, _ := strings.Cut(userAgent, "cluster/")
-
-	// Get rid of any text that would follow after cluster ID
-	clusterID = strings.Split(clusterID, " ")[0]
-	clusterID = strings.Split(clusterID, ",")[0]
 	return clusterID
 }
@@ -17,13 +17,21 @@ limitations under the License.
 package service_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"path/filepath"
 	"testing"
 
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 const (
@@ -178,7 +186,7 @@ func TestReadRulesCanaryRollout(t *testing.T) {
 
 func checkConditionalRules(t *testing.T, storage *service.Storage, rulesFile string, expectedRules service.Rules, r *http.Request) {
 	var rules service.Rules
-	data := storage.ReadConditionalRules(storage.IsCanary(r), rulesFile)
+	data, _ := storage.ReadConditionalRules(context.Background(), storage.IsCanary(r), rulesFile)
 	if len(data) == 0 {
 		rules = service.Rules{}
 	} else {
@@ -190,7 +198,7 @@ func checkConditionalRules(t *testing.T, storage *service.Storage, rulesFile str
 
 func checkRemoteConfig(t *testing.T, storage *service.Storage, remoteConfigFile string, expectedRemoteConfig service.RemoteConfiguration, _ *http.Request) {
 	var remoteConfig service.RemoteConfiguration
-	data := storage.ReadRemoteConfig(remoteConfigFile)
+	data, _ := storage.ReadRemoteConfig(context.Background(), remoteConfigFile)
 	if len(data) == 0 {
 		remoteConfig = service.RemoteConfiguration{}
 	} else {
@@ -240,6 +248,60 @@ func TestReadRemoteConfiguration(t *testing.T) {
 	}
 }
 
+// TestReadRemoteConfigErrors checks the errors returned for missing and
+// unreadable resources
+func TestReadRemoteConfigErrors(t *testing.T) {
+	storage, err := service.NewStorage(
+		service.StorageConfig{
+			RemoteConfigurationsPath: v2Folder,
+		}, false, nil)
+	assert.NoError(t, err)
+
+	_, err = storage.ReadRemoteConfig(context.Background(), filepath.Join(v2Folder, "stable", "not-found.json"))
+	var notFoundErr *merrors.StorageNotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+
+	_, err = storage.ReadRemoteConfig(context.Background(), filepath.Join(v2Folder, "stable"))
+	var ioErr *merrors.StorageIOError
+	assert.ErrorAs(t, err, &ioErr)
+}
+
+// TestReadRemoteConfigSpans checks that the storage reads are traced with
+// the cache hit or miss
+func TestReadRemoteConfigSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	originalProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(originalProvider)
+	})
+
+	storage, err := service.NewStorage(
+		service.StorageConfig{
+			RemoteConfigurationsPath: v2Folder,
+		}, false, nil)
+	require.NoError(t, err)
+	exporter.Reset()
+
+	remoteConfigFile := filepath.Join(v2Folder, "stable", validRulesFile)
+	for range 2 {
+		_, err = storage.ReadRemoteConfig(context.Background(), remoteConfigFile)
+		require.NoError(t, err)
+	}
+	_, err = storage.ReadRemoteConfig(context.Background(), filepath.Join(v2Folder, "stable", "not-found.json"))
+	require.Error(t, err)
+
+	// the remote configurations are cached when they are validated by
+	// NewStorage
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Contains(t, spans[0].Attributes, attribute.Bool("storage.cache_hit", true))
+	assert.Contains(t, spans[1].Attributes, attribute.Bool("storage.cache_hit", true))
+	assert.Contains(t, spans[2].Attributes, attribute.Bool("storage.cache_hit", false))
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+	assert.Equal(t, codes.Error, spans[2].Status.Code)
+}
+
 func TestGetRemoteConfigurationCanaryRollout(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -268,7 +330,7 @@ func TestGetRemoteConfigurationCanaryRollout(t *testing.T) {
 			req, err := http.NewRequest("GET", "http://example.com", nil)
 			assert.NoError(t, err)
 			req.Header.Add("User-Agent", tt.canaryArgument)
-			remoteConfigFile, err := storage.GetRemoteConfigurationFilepath(storage.IsCanary(req), "4.17.0")
+			remoteConfigFile, err := storage.GetRemoteConfigurationFilepath(context.Background(), storage.IsCanary(req), "4.17.0")
 			assert.NoError(t, err)
 			checkRemoteConfig(t, storage, remoteConfigFile, tt.expectedRemoteConfig, req)
 		})
@@ -0,0 +1,206 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+)
+
+// Limits of the container logs requests. The patterns are matched by every
+// cluster against the logs of the containers, so they must stay cheap.
+const (
+	// MaxContainerLogMessages is the maximum number of message patterns of
+	// a container logs request
+	MaxContainerLogMessages = 64
+	// MaxPatternLength is the maximum length of a pattern
+	MaxPatternLength = 1024
+	// MaxPatternInstructions is the maximum size of a compiled pattern
+	MaxPatternInstructions = 2048
+	// maxNamespaceLength is the maximum length of a DNS-1123 label
+	maxNamespaceLength = 63
+)
+
+var namespaceRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Validate checks the namespace is a DNS-1123 label and the patterns are
+// valid regular expressions within the limits
+func (r *ContainerLogRequest) Validate() error {
+	var errs []error
+	if len(r.Namespace) > maxNamespaceLength || !namespaceRegexp.MatchString(r.Namespace) {
+		errs = append(errs, fmt.Errorf("namespace '%s' is not a valid DNS-1123 label", r.Namespace))
+	}
+	if err := validatePattern(r.PodNameRegex); err != nil {
+		errs = append(errs, fmt.Errorf("pod_name_regex: %w", err))
+	}
+	if len(r.Messages) > MaxContainerLogMessages {
+		errs = append(errs, fmt.Errorf("%d messages exceed the limit of %d", len(r.Messages), MaxContainerLogMessages))
+	}
+	for i, message := range r.Messages {
+		if err := validatePattern(message); err != nil {
+			errs = append(errs, fmt.Errorf("messages[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validatePattern checks the pattern can be compiled and its length and
+// compiled size are within the limits
+func validatePattern(pattern string) error {
+	if len(pattern) > MaxPatternLength {
+		return fmt.Errorf("pattern length %d exceeds the limit of %d", len(pattern), MaxPatternLength)
+	}
+
+	// the patterns are compiled by the operator with regexp.Compile
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return err
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return err
+	}
+	if len(prog.Inst) > MaxPatternInstructions {
+		return fmt.Errorf("pattern '%s' is too complex", pattern)
+	}
+	return nil
+}
+
+// validateContainerLogRequests returns *merrors.InvalidEntryError for every
+// invalid container logs request of the file
+func validateContainerLogRequests(path string, requests []ContainerLogRequest) error {
+	var errs []error
+	for i := range requests {
+		if err := requests[i].Validate(); err != nil {
+			errs = append(errs, &merrors.InvalidEntryError{
+				Path:  path,
+				Kind:  "container_logs",
+				Index: i,
+				Err:   err,
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateRules returns *merrors.InvalidEntryError for every rule of the
+// file with invalid operator version range, duplicate ID or metadata
+func validateRules(path string, rules []Rule) error {
+	invalidIDs := validateRuleIDs(rules)
+	var errs []error
+	for i := range rules {
+		if err := errors.Join(rules[i].OperatorVersionRange.Validate(), invalidIDs[i]); err != nil {
+			errs = append(errs, &merrors.InvalidEntryError{
+				Path:  path,
+				Kind:  "conditional_gathering_rules",
+				Index: i,
+				Err:   err,
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateRemoteConfigurations reads all the remote configurations of the
+// cluster mappings with their fallbacks, resolves their includes and
+// validates them. All the invalid files and entries are reported.
+func (s *Storage) validateRemoteConfigurations() error {
+	var errs []error
+	validated := map[string]bool{}
+	fallbacks := map[string]string{}
+
+	resolver := newIncludeResolver(func(path string) ([]byte, error) {
+		return s.readDataFromPath(context.Background(), path)
+	})
+
+	queue := append(s.stableClusterMapping.Filepaths(), s.canaryClusterMapping.Filepaths()...)
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if validated[path] {
+			continue
+		}
+		validated[path] = true
+
+		fallback, err := s.validateRemoteConfiguration(path, resolver)
+		errs = append(errs, err)
+		if fallback != "" {
+			fallbacks[path] = fallback
+			queue = append(queue, fallback)
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(fallbacks)) {
+		seen := map[string]bool{path: true}
+		for next, found := fallbacks[path]; found; next, found = fallbacks[next] {
+			if seen[next] {
+				errs = append(errs, &merrors.CorruptDataError{Path: path, Err: errors.New("the fallbacks make a cycle")})
+				break
+			}
+			seen[next] = true
+		}
+	}
+
+	s.remoteConfigurationFilepaths = slices.Sorted(maps.Keys(validated))
+	return errors.Join(errs...)
+}
+
+// validateRemoteConfiguration composes the remote configuration with its
+// fragments, validates it and returns the path of its fallback. The composed
+// configuration replaces the content of the file in the cache.
+func (s *Storage) validateRemoteConfiguration(path string, resolver *includeResolver) (string, error) {
+	composed, err := resolver.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if composed != nil {
+		s.cache.Set(path, composed)
+	}
+	data, err := s.readDataFromPath(context.Background(), path)
+	if err != nil {
+		return "", err
+	}
+
+	var remoteConfig RemoteConfiguration
+	if err := json.Unmarshal(data, &remoteConfig); err != nil {
+		return "", &merrors.CorruptDataError{Path: path, Err: err}
+	}
+	if err := remoteConfig.OperatorVersionRange.Validate(); err != nil {
+		return "", &merrors.CorruptDataError{Path: path, Err: err}
+	}
+
+	var fallback string
+	if remoteConfig.Fallback != "" {
+		fallback, err = fallbackFilepath(path, remoteConfig.Fallback)
+		if err != nil {
+			return "", &merrors.CorruptDataError{Path: path, Err: err}
+		}
+	}
+
+	return fallback, errors.Join(
+		validateRules(path, remoteConfig.ConditionalRules),
+		validateContainerLogRequests(path, remoteConfig.ContainerLogsRequests),
+	)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	merrors "github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/errors"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+)
+
+func TestContainerLogRequestValidate(t *testing.T) {
+	valid := func() service.ContainerLogRequest {
+		return service.ContainerLogRequest{
+			Namespace:    "openshift-monitoring",
+			PodNameRegex: "prometheus-k8s-.*",
+			Messages:     []string{"error", `level=(warn|error)`},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		modify        func(r *service.ContainerLogRequest)
+		expectedError string
+	}{
+		{"valid request", func(*service.ContainerLogRequest) {}, ""},
+		{"no messages", func(r *service.ContainerLogRequest) { r.Messages = nil }, ""},
+		{
+			"namespace with upper case letters",
+			func(r *service.ContainerLogRequest) { r.Namespace = "Openshift" },
+			"namespace 'Openshift' is not a valid DNS-1123 label",
+		},
+		{
+			"empty namespace",
+			func(r *service.ContainerLogRequest) { r.Namespace = "" },
+			"namespace '' is not a valid DNS-1123 label",
+		},
+		{
+			"too long namespace",
+			func(r *service.ContainerLogRequest) { r.Namespace = strings.Repeat("a", 64) },
+			"is not a valid DNS-1123 label",
+		},
+		{
+			"invalid pod name regex",
+			func(r *service.ContainerLogRequest) { r.PodNameRegex = "prometheus-(k8s" },
+			"pod_name_regex: error parsing regexp: missing closing )",
+		},
+		{
+			"invalid message",
+			func(r *service.ContainerLogRequest) { r.Messages = []string{"error", "[a-"} },
+			"messages[1]: error parsing regexp",
+		},
+		{
+			"too many messages",
+			func(r *service.ContainerLogRequest) {
+				r.Messages = make([]string, service.MaxContainerLogMessages+1)
+			},
+			"65 messages exceed the limit of 64",
+		},
+		{
+			"too long pattern",
+			func(r *service.ContainerLogRequest) {
+				r.Messages = []string{strings.Repeat("a", service.MaxPatternLength+1)}
+			},
+			"messages[0]: pattern length 1025 exceeds the limit of 1024",
+		},
+		{
+			"too complex pattern",
+			func(r *service.ContainerLogRequest) { r.PodNameRegex = "a{1000}b{1000}c{1000}" },
+			"pod_name_regex: pattern 'a{1000}b{1000}c{1000}' is too complex",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := valid()
+			tc.modify(&request)
+			err := request.Validate()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+// TestStorageInvalidContainerLogRequests checks that all the invalid
+// container logs requests are reported with their file and index
+func TestStorageInvalidContainerLogRequests(t *testing.T) {
+	_, err := service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: "testdata/v2_invalid_container_logs",
+	}, false, nil)
+
+	var invalidErr *merrors.InvalidEntryError
+	assert.ErrorAs(t, err, &invalidErr)
+	assert.ErrorContains(t, err, "container_logs[1] of 'testdata/v2_invalid_container_logs/stable/rules.json' is invalid")
+	assert.ErrorContains(t, err, "container_logs[0] of 'testdata/v2_invalid_container_logs/canary/rules.json' is invalid")
+
+	_, err = service.NewStorage(service.StorageConfig{
+		RemoteConfigurationsPath: v2Folder,
+		OverlaysPath:             "testdata/overlays_invalid_container_logs",
+	}, false, nil)
+	assert.ErrorContains(t, err, "container_logs[0] of 'testdata/overlays_invalid_container_logs/orgs/123456.json' is invalid")
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing configures the OpenTelemetry tracing of the service
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// ExporterOTLP sends the spans to OTLP collector over HTTP
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes the spans to the standard output
+	ExporterStdout = "stdout"
+
+	defaultServiceName = "insights-operator-gathering-conditions-service"
+)
+
+// Config structure represents the tracing settings. Spans are sampled with
+// given ratio, unless the parent span of the caller is sampled.
+type Config struct {
+	Enabled     bool    `mapstructure:"enabled" toml:"enabled"`
+	Exporter    string  `mapstructure:"exporter" toml:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint" toml:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure" toml:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio" toml:"sample_ratio"`
+	ServiceName string  `mapstructure:"service_name" toml:"service_name"`
+}
+
+// ShutdownFunc flushes the pending spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Init configures the global tracer provider and W3C trace context
+// propagation. The propagation is enabled even when the tracing is disabled,
+// so the trace context of incoming requests is not lost.
+func Init(cfg Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		log.Info().Msg("Tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(cfg, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := NewTracerProvider(cfg, exporter)
+	otel.SetTracerProvider(provider)
+
+	log.Info().
+		Str("exporter", cfg.Exporter).
+		Str("endpoint", cfg.Endpoint).
+		Float64("sampleRatio", sampleRatio(cfg)).
+		Msg("Tracing enabled")
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider constructs the tracer provider exporting the spans by
+// given exporter in batches
+func NewTracerProvider(cfg Config, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio(cfg)))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+		)),
+	)
+}
+
+// sampleRatio returns the configured ratio, all traces are sampled by default
+func sampleRatio(cfg Config) float64 {
+	if cfg.SampleRatio <= 0 {
+		return 1
+	}
+	return cfg.SampleRatio
+}
+
+func newExporter(cfg Config, stdout io.Writer) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		// the exporter connects lazily, so it doesn't fail when the
+		// collector is not available yet
+		return otlptracehttp.New(context.Background(), options...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(stdout))
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter '%s'", cfg.Exporter)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2026 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/tracing"
+)
+
+// TestInitDisabled checks that the trace context is propagated even when
+// the tracing is disabled
+func TestInitDisabled(t *testing.T) {
+	shutdown, err := tracing.Init(tracing.Config{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	carrier := propagation.MapCarrier{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	injected := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, injected)
+	assert.Equal(t, carrier["traceparent"], injected["traceparent"])
+}
+
+// TestInitUnsupportedExporter checks that unknown exporter is reported
+func TestInitUnsupportedExporter(t *testing.T) {
+	_, err := tracing.Init(tracing.Config{Enabled: true, Exporter: "zipkin"})
+	assert.EqualError(t, err, "unsupported tracing exporter 'zipkin'")
+}
+
+// TestNewExporter checks the construction of the supported exporters
+func TestNewExporter(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  tracing.Config
+	}{
+		{"OTLP exporter", tracing.Config{Exporter: tracing.ExporterOTLP, Endpoint: "localhost:4318", Insecure: true}},
+		{"OTLP exporter with default endpoint", tracing.Config{Exporter: tracing.ExporterOTLP}},
+		{"stdout exporter", tracing.Config{Exporter: tracing.ExporterStdout}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter, err := tracing.NewExporter(tc.cfg, &bytes.Buffer{})
+			require.NoError(t, err)
+			assert.NoError(t, exporter.Shutdown(context.Background()))
+		})
+	}
+}
+
+// TestStdoutExporter checks that the spans are written with the service name
+func TestStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := tracing.Config{Enabled: true, Exporter: tracing.ExporterStdout, ServiceName: "test-service"}
+	exporter, err := tracing.NewExporter(cfg, &buf)
+	require.NoError(t, err)
+
+	provider := tracing.NewTracerProvider(cfg, exporter)
+	_, span := provider.Tracer("test").Start(context.Background(), "test span")
+	span.End()
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	var exported struct {
+		Name     string
+		Resource []struct {
+			Key   string
+			Value struct{ Value interface{} }
+		}
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+	assert.Equal(t, "test span", exported.Name)
+	require.Len(t, exported.Resource, 1)
+	assert.Equal(t, "service.name", exported.Resource[0].Key)
+	assert.Equal(t, "test-service", exported.Resource[0].Value.Value)
+}
@@ -19,16 +19,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/cli"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/config"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/server"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/tracing"
 	"github.com/RedHatInsights/insights-operator-utils/logger"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
@@ -117,18 +118,71 @@ func InitService() (*service.Service, error) {
 		return nil, err
 	}
 
+	// Deny-list of the rules
+	killSwitch, err := service.NewKillSwitch(storageConfig.DenyListPath)
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading the deny-list")
+		return nil, err
+	}
+	if err = killSwitch.Watch(); err != nil {
+		log.Error().Err(err).Msg("Error watching the deny-list")
+		return nil, err
+	}
+
+	// Snapshots served to the channels
+	releases, err := service.NewReleases(storageConfig.ReleaseStatePath, store.SnapshotIDs())
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading the release state")
+		return nil, err
+	}
+
+	// Guardrail clearing the canary snapshot
+	guardrailConfig := config.GuardrailConfig()
+	if err = guardrailConfig.Validate(); err != nil {
+		log.Error().Err(err).Msg("Invalid guardrail configuration")
+		return nil, err
+	}
+
 	// Repository & Service
 	repo := service.NewRepository(store)
+	repo.SetReleases(releases)
+	repo.SetKillSwitch(killSwitch)
+	repo.SetGuardrail(service.NewGuardrail(guardrailConfig))
+	repo.SetStripMetadata(storageConfig.StripRuleMetadata)
+
+	// Ledger of the served remote configurations
+	ledgerConfig := config.LedgerConfig()
+	if ledgerConfig.Enabled {
+		ledgerStore, err := service.OpenLedgerStore(ledgerConfig)
+		if err != nil {
+			log.Error().Err(err).Msg("Error opening the ledger")
+			return nil, err
+		}
+		repo.SetLedger(service.NewLedger(ledgerStore, ledgerConfig))
+	}
 	return service.New(repo), nil
 }
 
 // RunServer starts the server with the loaded configuration. Make sure to call
 // config.LoadConfiguration in order not to start with an empty configuration.
 func RunServer() error {
-	var httpServer *server.Server
-
 	serverConfig := config.ServerConfig()
 	authConfig := config.AuthConfig()
+	adminServerConfig := config.AdminServerConfig()
+	adminAuthConfig := config.AdminAuthConfig()
+	operationsConfig := config.OperationsConfig()
+
+	// the admin operations change the served rules, so they are never
+	// served by the public listener
+	if err := operationsConfig.Validate(); err != nil {
+		log.Error().Err(err).Msg("Invalid configuration of the admin operations")
+		return err
+	}
+	if operationsConfig.Enabled && adminServerConfig.Address == "" {
+		err := errors.New("the admin operations are enabled, but the admin listener is not configured")
+		log.Error().Err(err).Msg("Invalid configuration of the admin operations")
+		return err
+	}
 
 	svc, err := InitService()
 	if err != nil {
@@ -136,6 +190,12 @@ func RunServer() error {
 		return err
 	}
 
+	shutdownTracing, err := tracing.Init(config.TracingConfig())
+	if err != nil {
+		log.Error().Err(err).Msg("Error occurred during tracing initialization")
+		return err
+	}
+
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(interrupt)
@@ -146,18 +206,42 @@ func RunServer() error {
 
 	g, ctx := errgroup.WithContext(ctx)
 
-	// HTTP
-	g.Go(func() error {
-		router := mux.NewRouter().StrictSlash(true)
-
-		// Register the service
-		service.NewHandler(svc).Register(router)
+	handler := service.NewHandler(svc)
+
+	// Register the service
+	router := mux.NewRouter().StrictSlash(true)
+	handler.Register(router)
+
+	// Create the HTTP Server
+	httpServer := server.New(serverConfig, authConfig, router)
+	httpServer.RateLimitKey = service.GetClusterID
+	httpServers := []*server.Server{httpServer}
+
+	// Metrics, health and debug endpoints are served by the admin listener
+	// if it's configured, so they don't share the auth. settings with the
+	// public API. The other admin endpoints are never served by the public
+	// listener.
+	if adminServerConfig.Address != "" {
+		adminRouter := mux.NewRouter().StrictSlash(true)
+		handler.RegisterMonitoring(adminRouter)
+		handler.RegisterAdmin(adminRouter)
+		handler.RegisterDebug(adminRouter)
+		if operationsConfig.Enabled {
+			handler.RegisterOperations(adminRouter, operationsConfig.Token)
+		}
 
-		// Create the HTTP Server
-		httpServer = server.New(serverConfig, authConfig, router)
+		adminServer := server.New(adminServerConfig, adminAuthConfig, adminRouter)
+		adminServer.NoAuthURLs = []string{service.HealthPath, service.ReadinessPath}
+		httpServers = append(httpServers, adminServer)
+	} else {
+		log.Warn().Msg("Admin listener is not configured, serving metrics by the public listener and disabling the admin endpoints")
+		handler.RegisterMonitoring(router)
+	}
 
-		return httpServer.Start()
-	})
+	// HTTP
+	for _, s := range httpServers {
+		g.Go(s.Start)
+	}
 
 	select {
 	case <-interrupt:
@@ -170,18 +254,28 @@ func RunServer() error {
 
 	cancel()
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), serverConfig.GracefulShutdownTimeout())
 	defer shutdownCancel()
 
-	stopHTTPServer(shutdownCtx, g, httpServer)
+	stopHTTPServers(shutdownCtx, g, httpServers)
+
+	// write the ledger records of the last requests
+	if err := svc.Close(); err != nil {
+		log.Error().Err(err).Msg("Unable to close the service")
+	}
+
+	// flush the spans of the last requests
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Unable to flush the tracing spans")
+	}
 
 	log.Info().Msg("Server closed")
 	return nil
 }
 
-// stopHTTPServer function initialize the HTTP server shutdown operations
-func stopHTTPServer(shutdownCtx context.Context, g *errgroup.Group, httpServer *server.Server) {
-	if httpServer != nil {
+// stopHTTPServers function initialize the HTTP servers shutdown operations
+func stopHTTPServers(shutdownCtx context.Context, g *errgroup.Group, httpServers []*server.Server) {
+	for _, httpServer := range httpServers {
 		err := httpServer.Stop(shutdownCtx)
 		if err != nil {
 			log.Error().Err(err).Msg("HTTP(s) server Shutdown operation failure")
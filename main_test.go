@@ -12,6 +12,7 @@ import (
 	main "github.com/RedHatInsights/insights-operator-gathering-conditions-service"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/cli"
 	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/config"
+	"github.com/RedHatInsights/insights-operator-gathering-conditions-service/internal/service"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -113,6 +114,14 @@ func TestRunService(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, wantResponses, resp.StatusCode)
 
+	// metrics and health endpoints are served by the admin listener
+	for _, path := range []string{service.HealthPath, service.MetricsPath} {
+		resp, err = client.Get(
+			fmt.Sprintf("http://localhost%s%s", config.AdminServerConfig().Address, path))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
 	stopServer(t)
 }
 